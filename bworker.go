@@ -0,0 +1,20 @@
+// Package bworker list the live worker pools of a process, so a debug endpoint or a CLI can tell them apart by the
+// name set with pool.WithName.
+package bworker
+
+import "github.com/bearaujus/bworker/internal"
+
+// PoolInfo is a snapshot of a live worker pool returned by Pools.
+type PoolInfo = internal.PoolInfo
+
+// RunningJob describe a job being executed by a worker of a pool listed by Pools.
+type RunningJob = internal.RunningJob
+
+// JobError describe one of the latest failed jobs of a pool listed by Pools.
+type JobError = internal.JobError
+
+// Pools return the PoolInfo of every BWorkerPool, BWorkerKeyedPool and sub-pool that is not shut down, sorted by
+// name and then by the time they are started. A pool is listed again once it is restarted.
+func Pools() []PoolInfo {
+	return internal.Pools()
+}
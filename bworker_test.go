@@ -0,0 +1,46 @@
+package bworker
+
+import (
+	"github.com/bearaujus/bworker/pool"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPools(t *testing.T) {
+	names := func() []string {
+		var names []string
+		for _, info := range Pools() {
+			names = append(names, info.Name)
+		}
+		return names
+	}
+	assert.Empty(t, Pools())
+
+	emails := pool.NewBWorkerPool(2, pool.WithName("emails"))
+	orders := pool.NewKeyedPool(1, pool.WithName("orders"))
+	unnamed := pool.NewBWorkerPool(1, pool.WithName(""))
+	sub := emails.Sub(1, pool.WithName("emails-sub"))
+	assert.Equal(t, "emails", emails.Name())
+	assert.Equal(t, "", unnamed.Name())
+	assert.Equal(t, []string{"", "emails", "emails-sub", "orders"}, names())
+
+	emails.DoSimple(func() {})
+	emails.Wait()
+	info := Pools()[1]
+	assert.Equal(t, "running", info.State)
+	assert.Equal(t, 2, info.Concurrency)
+	assert.Equal(t, int64(1), info.Stats.Succeeded)
+	assert.Equal(t, int64(2), info.Stats.IdleWorkers)
+
+	// Shutdown of a pool shuts down its sub-pools
+	emails.Shutdown()
+	assert.Equal(t, []string{"", "orders"}, names())
+	emails.Restart()
+	assert.Equal(t, []string{"", "emails", "orders"}, names())
+	assert.True(t, sub.IsDead())
+
+	emails.Shutdown()
+	orders.Shutdown()
+	unnamed.Shutdown()
+	assert.Empty(t, Pools())
+}
@@ -0,0 +1,242 @@
+package dag
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bearaujus/bworker/internal"
+	"github.com/bearaujus/bworker/pool"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrCycle is returned by BWorkerDAG.Run when the jobs depend on each other in a cycle.
+	ErrCycle = errors.New("dependency cycle")
+
+	// ErrDependencyFailed is the error of a job that is skipped because one of its dependencies did not succeed.
+	ErrDependencyFailed = errors.New("dependency failed")
+
+	// ErrPoolShutdown is the error of a job that is skipped because the worker pool is shut down.
+	ErrPoolShutdown = errors.New("worker pool is shut down")
+)
+
+// Status is the final state of a job in a BWorkerDAG run.
+type Status int
+
+const (
+	// StatusSucceeded indicates the job returned no error.
+	StatusSucceeded Status = iota
+	// StatusFailed indicates the job still returned an error after its retries.
+	StatusFailed
+	// StatusSkipped indicates the job was not executed, because one of its dependencies did not succeed or the
+	// worker pool is shut down.
+	StatusSkipped
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusSucceeded:
+		return "succeeded"
+	case StatusFailed:
+		return "failed"
+	case StatusSkipped:
+		return "skipped"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// Result is the outcome of a job in a BWorkerDAG run.
+type Result struct {
+	Status Status
+	// Err is the last error returned by the job when it failed, or the reason it was skipped.
+	Err error
+}
+
+type BWorkerDAG interface {
+	// Add register a job with a unique name that depends on the jobs named by deps. A dependency can be added
+	// after the jobs that depend on it, as long as it is added before Run.
+	Add(name string, job func() error, deps ...string) error
+
+	// Run execute the registered jobs on the worker pool and wait for all of them to be completed. Each job is
+	// submitted as soon as all of its dependencies succeeded, and the jobs that depend on a job that did not
+	// succeed are skipped.
+	//
+	// Run returns an error without executing any job if a dependency is unknown or the jobs depend on each other
+	// in a cycle. Otherwise, it returns the Result of every job by its name.
+	Run(bwp pool.BWorkerPool) (map[string]Result, error)
+}
+
+type bWorkerDAG struct {
+	mu    *sync.Mutex
+	nodes map[string]*node
+	// order holds the node names by the time they are added, so jobs are submitted in a stable order.
+	order []string
+}
+
+type node struct {
+	job  func() error
+	deps []string
+}
+
+// NewBWorkerDAG create a new BWorkerDAG without any job.
+func NewBWorkerDAG() BWorkerDAG {
+	return &bWorkerDAG{
+		mu:    &sync.Mutex{},
+		nodes: make(map[string]*node),
+	}
+}
+
+func (bwd *bWorkerDAG) Add(name string, job func() error, deps ...string) error {
+	if job == nil {
+		return fmt.Errorf("job %q is nil", name)
+	}
+	bwd.mu.Lock()
+	defer bwd.mu.Unlock()
+	if _, ok := bwd.nodes[name]; ok {
+		return fmt.Errorf("job %q is already added", name)
+	}
+	bwd.nodes[name] = &node{job: job, deps: append([]string(nil), deps...)}
+	bwd.order = append(bwd.order, name)
+	return nil
+}
+
+type completion struct {
+	name string
+	err  error
+}
+
+func (bwd *bWorkerDAG) Run(bwp pool.BWorkerPool) (map[string]Result, error) {
+	bwd.mu.Lock()
+	defer bwd.mu.Unlock()
+	if err := bwd.validate(); err != nil {
+		return nil, err
+	}
+	var (
+		results  = make(map[string]Result, len(bwd.nodes))
+		children = make(map[string][]string, len(bwd.nodes))
+		waiting  = make(map[string]int, len(bwd.nodes))
+		// Buffered to the number of jobs, so the workers never block when reporting the completion
+		completions = make(chan completion, len(bwd.nodes))
+		running     int
+	)
+	for _, name := range bwd.order {
+		waiting[name] = len(bwd.nodes[name].deps)
+		for _, dep := range bwd.nodes[name].deps {
+			children[dep] = append(children[dep], name)
+		}
+	}
+	var resolve func(name string, res Result)
+	submit := func(name string) {
+		job := bwd.nodes[name].job
+		if cd, ok := bwp.(internal.ChainDoer); ok {
+			ok = cd.DoChain(internal.Chain{Job: job, Next: func(err error) bool {
+				completions <- completion{name: name, err: err}
+				return false
+			}})
+			if !ok {
+				resolve(name, Result{Status: StatusSkipped, Err: ErrPoolShutdown})
+				return
+			}
+		} else {
+			// The completion of the retries is unknown, so the job is executed without the retry of the worker pool
+			if bwp.IsDead() {
+				resolve(name, Result{Status: StatusSkipped, Err: ErrPoolShutdown})
+				return
+			}
+			bwp.DoSimple(func() {
+				completions <- completion{name: name, err: job()}
+			})
+		}
+		running++
+	}
+	// resolve record the result of a job and submit or skip the jobs that no longer wait for a dependency.
+	resolve = func(name string, res Result) {
+		results[name] = res
+		for _, child := range children[name] {
+			waiting[child]--
+			if waiting[child] != 0 {
+				continue
+			}
+			var failed []string
+			for _, dep := range bwd.nodes[child].deps {
+				if results[dep].Status != StatusSucceeded {
+					failed = append(failed, dep)
+				}
+			}
+			if len(failed) != 0 {
+				resolve(child, Result{
+					Status: StatusSkipped,
+					Err:    fmt.Errorf("%w: %v", ErrDependencyFailed, strings.Join(failed, ", ")),
+				})
+				continue
+			}
+			submit(child)
+		}
+	}
+	var roots []string
+	for _, name := range bwd.order {
+		if waiting[name] == 0 {
+			roots = append(roots, name)
+		}
+	}
+	for _, name := range roots {
+		submit(name)
+	}
+	for running != 0 {
+		c := <-completions
+		running--
+		if c.err != nil {
+			resolve(c.name, Result{Status: StatusFailed, Err: c.err})
+			continue
+		}
+		resolve(c.name, Result{Status: StatusSucceeded})
+	}
+	return results, nil
+}
+
+// validate check every dependency is known and there is no dependency cycle.
+func (bwd *bWorkerDAG) validate() error {
+	for _, name := range bwd.order {
+		for _, dep := range bwd.nodes[name].deps {
+			if _, ok := bwd.nodes[dep]; !ok {
+				return fmt.Errorf("job %q depends on unknown job %q", name, dep)
+			}
+		}
+	}
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(bwd.nodes))
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			for i, v := range path {
+				if v == name {
+					return fmt.Errorf("%w: %v", ErrCycle, strings.Join(append(path[i:], name), " -> "))
+				}
+			}
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range bwd.nodes[name].deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+	for _, name := range bwd.order {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package dag
+
+import (
+	"errors"
+	"github.com/bearaujus/bworker/pool"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDAG(t *testing.T) {
+	type args struct {
+		concurrency int
+		opts        []pool.OptionPool
+	}
+	tests := []struct {
+		name        string
+		args        args
+		jobs        func(bwd BWorkerDAG, bwp pool.BWorkerPool) *int64
+		wantRet     int64
+		wantErrsLen int
+	}{
+		{
+			name: "test add invalid jobs",
+			args: args{
+				concurrency: 1,
+			},
+			jobs: func(bwd BWorkerDAG, bwp pool.BWorkerPool) *int64 {
+				var ret int64
+
+				assert.Error(t, bwd.Add("a", nil))
+				assert.NoError(t, bwd.Add("a", func() error { return nil }))
+				assert.Error(t, bwd.Add("a", func() error { return nil }))
+				return &ret
+			},
+			wantRet:     0,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test run with unknown dependency",
+			args: args{
+				concurrency: 1,
+			},
+			jobs: func(bwd BWorkerDAG, bwp pool.BWorkerPool) *int64 {
+				var ret int64
+
+				assert.NoError(t, bwd.Add("a", func() error {
+					ret++
+					return nil
+				}))
+				assert.NoError(t, bwd.Add("b", func() error {
+					ret++
+					return nil
+				}, "a", "c"))
+				results, err := bwd.Run(bwp)
+				assert.EqualError(t, err, `job "b" depends on unknown job "c"`)
+				assert.Nil(t, results)
+				return &ret
+			},
+			wantRet:     0,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test run with cycle",
+			args: args{
+				concurrency: 1,
+			},
+			jobs: func(bwd BWorkerDAG, bwp pool.BWorkerPool) *int64 {
+				var ret int64
+
+				job := func() error {
+					ret++
+					return nil
+				}
+				assert.NoError(t, bwd.Add("a", job))
+				assert.NoError(t, bwd.Add("b", job, "a", "d"))
+				assert.NoError(t, bwd.Add("c", job, "b"))
+				assert.NoError(t, bwd.Add("d", job, "c"))
+				results, err := bwd.Run(bwp)
+				assert.ErrorIs(t, err, ErrCycle)
+				assert.EqualError(t, err, "dependency cycle: b -> d -> c -> b")
+				assert.Nil(t, results)
+				return &ret
+			},
+			wantRet:     0,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test run in dependency order",
+			args: args{
+				concurrency: 10,
+			},
+			jobs: func(bwd BWorkerDAG, bwp pool.BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				finished := make(map[string]time.Duration)
+				start := time.Now()
+				record := func(name string, deps ...string) func() error {
+					return func() error {
+						mu.Lock()
+						for _, dep := range deps {
+							_, ok := finished[dep]
+							assert.True(t, ok)
+						}
+						mu.Unlock()
+						time.Sleep(time.Millisecond * 300)
+						mu.Lock()
+						defer mu.Unlock()
+						finished[name] = time.Since(start)
+						ret++
+						return nil
+					}
+				}
+				// a -> (b, c) -> d, while e runs alongside
+				assert.NoError(t, bwd.Add("d", record("d", "b", "c"), "b", "c"))
+				assert.NoError(t, bwd.Add("a", record("a")))
+				assert.NoError(t, bwd.Add("b", record("b", "a"), "a"))
+				assert.NoError(t, bwd.Add("c", record("c", "a"), "a"))
+				assert.NoError(t, bwd.Add("e", record("e")))
+				results, err := bwd.Run(bwp)
+				assert.NoError(t, err)
+				assert.Len(t, results, 5)
+				for _, res := range results {
+					assert.Equal(t, Result{Status: StatusSucceeded}, res)
+				}
+				// The total executed time should be around ~900 ms
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Millisecond*900, ts)
+				assert.LessOrEqual(t, ts, (time.Millisecond*900)+(time.Millisecond*100)) // Add 0.1s as a threshold
+				return &ret
+			},
+			wantRet:     5,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test run with failure",
+			args: args{
+				concurrency: 10,
+				opts:        []pool.OptionPool{pool.WithRetry(2)},
+			},
+			jobs: func(bwd BWorkerDAG, bwp pool.BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				job := func(err error) func() error {
+					return func() error {
+						mu.Lock()
+						defer mu.Unlock()
+						ret++
+						return err
+					}
+				}
+				assert.NoError(t, bwd.Add("a", job(nil)))
+				assert.NoError(t, bwd.Add("b", job(errors.New("an error")), "a"))
+				assert.NoError(t, bwd.Add("c", job(nil), "a"))
+				assert.NoError(t, bwd.Add("d", job(nil), "b", "c"))
+				assert.NoError(t, bwd.Add("e", job(nil), "d"))
+				results, err := bwd.Run(bwp)
+				assert.NoError(t, err)
+				assert.Equal(t, Result{Status: StatusSucceeded}, results["a"])
+				assert.Equal(t, StatusFailed, results["b"].Status)
+				assert.EqualError(t, results["b"].Err, "an error")
+				assert.Equal(t, Result{Status: StatusSucceeded}, results["c"])
+				assert.Equal(t, StatusSkipped, results["d"].Status)
+				assert.ErrorIs(t, results["d"].Err, ErrDependencyFailed)
+				assert.EqualError(t, results["d"].Err, "dependency failed: b")
+				assert.Equal(t, StatusSkipped, results["e"].Status)
+				assert.EqualError(t, results["e"].Err, "dependency failed: d")
+				return &ret
+			},
+			wantRet:     1 + (1 + 2) + 1, // a + (base attempt + num retry) + c
+			wantErrsLen: 1,
+		},
+		{
+			name: "test run with panic",
+			args: args{
+				concurrency: 10,
+			},
+			jobs: func(bwd BWorkerDAG, bwp pool.BWorkerPool) *int64 {
+				var ret int64
+
+				assert.NoError(t, bwd.Add("a", func() error {
+					ret++
+					panic("a panic")
+				}))
+				assert.NoError(t, bwd.Add("b", func() error {
+					ret++
+					return nil
+				}, "a"))
+				results, err := bwd.Run(bwp)
+				assert.NoError(t, err)
+				assert.Equal(t, StatusFailed, results["a"].Status)
+				assert.ErrorIs(t, results["a"].Err, pool.ErrJobPanic)
+				assert.Equal(t, StatusSkipped, results["b"].Status)
+				assert.EqualError(t, results["b"].Err, "dependency failed: a")
+				return &ret
+			},
+			wantRet:     1,
+			wantErrsLen: 1,
+		},
+		{
+			name: "test run with timeout",
+			args: args{
+				concurrency: 10,
+				opts:        []pool.OptionPool{pool.WithJobTimeout(time.Millisecond * 100)},
+			},
+			jobs: func(bwd BWorkerDAG, bwp pool.BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				job := func(d time.Duration, err error) func() error {
+					return func() error {
+						mu.Lock()
+						ret++
+						mu.Unlock()
+						time.Sleep(d)
+						return err
+					}
+				}
+				// The job returns an error after the deadline
+				assert.NoError(t, bwd.Add("a", job(time.Millisecond*200, errors.New("an error"))))
+				assert.NoError(t, bwd.Add("b", job(0, nil)))
+				assert.NoError(t, bwd.Add("c", job(0, nil), "a", "b"))
+				results, err := bwd.Run(bwp)
+				assert.NoError(t, err)
+				assert.Equal(t, StatusFailed, results["a"].Status)
+				assert.ErrorIs(t, results["a"].Err, pool.ErrJobTimeout)
+				assert.Equal(t, Result{Status: StatusSucceeded}, results["b"])
+				assert.Equal(t, StatusSkipped, results["c"].Status)
+				assert.EqualError(t, results["c"].Err, "dependency failed: a")
+				return &ret
+			},
+			wantRet:     2,
+			wantErrsLen: 1,
+		},
+		{
+			name: "test run when the pool is shut down",
+			args: args{
+				concurrency: 1,
+			},
+			jobs: func(bwd BWorkerDAG, bwp pool.BWorkerPool) *int64 {
+				var ret int64
+
+				job := func() error {
+					ret++
+					return nil
+				}
+				assert.NoError(t, bwd.Add("a", job))
+				assert.NoError(t, bwd.Add("b", job, "a"))
+				bwp.Shutdown()
+				results, err := bwd.Run(bwp)
+				assert.NoError(t, err)
+				assert.Equal(t, Result{Status: StatusSkipped, Err: ErrPoolShutdown}, results["a"])
+				assert.Equal(t, StatusSkipped, results["b"].Status)
+				assert.ErrorIs(t, results["b"].Err, ErrDependencyFailed)
+				return &ret
+			},
+			wantRet:     0,
+			wantErrsLen: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errs []error
+			bwp := pool.NewBWorkerPool(tt.args.concurrency, append(tt.args.opts, pool.WithErrors(&errs))...)
+			defer bwp.Shutdown()
+			bwd := NewBWorkerDAG()
+			if tt.jobs != nil {
+				gotNumExecuted := tt.jobs(bwd, bwp)
+				bwp.Wait()
+				assert.Equal(t, tt.wantRet, *gotNumExecuted)
+			}
+			assert.Equal(t, tt.wantErrsLen, len(errs))
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	assert.Equal(t, "succeeded", StatusSucceeded.String())
+	assert.Equal(t, "failed", StatusFailed.String())
+	assert.Equal(t, "skipped", StatusSkipped.String())
+	assert.Equal(t, "Status(10)", Status(10).String())
+}
@@ -0,0 +1,313 @@
+package debughttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bearaujus/bworker/internal"
+	"html/template"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type BWorkerDebug interface {
+	// ServeHTTP list the pools of bworker.Pools on GET, as JSON when the request accepts application/json or has
+	// the format=json query, or as HTML otherwise. On POST, it performs the action on the pool with the id: pause, resume,
+	// or resize to the concurrency of at most 4096. The POST is a JSON body such as {"id":1,"action":"pause"}, or an
+	// HTML form with the same fields that is rejected unless its Origin or Referer is the host of the request, so
+	// another site can not control the pools through the browser of a user.
+	ServeHTTP(w http.ResponseWriter, r *http.Request)
+}
+
+type bWorkerDebug struct{}
+
+// maxConcurrency is the highest concurrency level of the resize action, so a request can not start an unbounded
+// number of workers.
+const maxConcurrency = 4096
+
+// NewBWorkerDebug create a new BWorkerDebug. It is an http.Handler to be served on any path, such as
+// /debug/bworker, and it does not depend on any external service.
+func NewBWorkerDebug() BWorkerDebug {
+	return &bWorkerDebug{}
+}
+
+func (bwd *bWorkerDebug) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		bwd.list(w, r)
+	case http.MethodPost:
+		bwd.control(w, r)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (bwd *bWorkerDebug) list(w http.ResponseWriter, r *http.Request) {
+	infos := internal.Pools()
+	pools := make([]poolView, len(infos))
+	for i, info := range infos {
+		pools[i] = newPoolView(info)
+	}
+	if wantJSON(r) {
+		writeJSON(w, http.StatusOK, poolsView{Pools: pools})
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = page.Execute(w, pageData{Path: r.URL.Path, Pools: pools})
+}
+
+type controlRequest struct {
+	ID          uint64 `json:"id"`
+	Action      string `json:"action"`
+	Concurrency int    `json:"concurrency"`
+}
+
+// control perform the action of a POST request, then write the pool as JSON, or redirect to the list for an HTML form.
+func (bwd *bWorkerDebug) control(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	isJSON := mediaType == "application/json"
+	var (
+		req controlRequest
+		err error
+	)
+	if isJSON {
+		err = readJSON(w, r, &req)
+	} else {
+		err = readForm(r, &req)
+	}
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, errCrossOrigin) {
+			code = http.StatusForbidden
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	pool, ok := internal.Lookup(req.ID)
+	if !ok {
+		http.Error(w, fmt.Sprintf("pool %d is not found", req.ID), http.StatusNotFound)
+		return
+	}
+	switch req.Action {
+	case "pause":
+		pool.Pause()
+	case "resume":
+		pool.Resume()
+	case "resize":
+		if req.Concurrency <= 0 || req.Concurrency > maxConcurrency {
+			http.Error(w, fmt.Sprintf("invalid concurrency %d, it must be between 1 and %d", req.Concurrency, maxConcurrency), http.StatusBadRequest)
+			return
+		}
+		pool.Resize(req.Concurrency)
+	default:
+		http.Error(w, fmt.Sprintf("invalid action %q", req.Action), http.StatusBadRequest)
+		return
+	}
+	if isJSON || wantJSON(r) {
+		info := pool.Info()
+		info.ID = req.ID
+		writeJSON(w, http.StatusOK, newPoolView(info))
+		return
+	}
+	http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
+}
+
+var errCrossOrigin = errors.New("the form is not posted from the same origin, post a JSON body instead")
+
+// readJSON read a control request from a JSON body. Another site can not post a JSON body from the browser of a user
+// without a CORS preflight, which is not allowed by this handler.
+func readJSON(w http.ResponseWriter, r *http.Request, req *controlRequest) error {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(req); err != nil {
+		return fmt.Errorf("invalid body: %v", err)
+	}
+	return nil
+}
+
+// readForm read a control request from an HTML form posted from the same origin. The form of another site is
+// rejected, since the browser posts it with the cookies of the user.
+func readForm(r *http.Request, req *controlRequest) error {
+	if !sameOrigin(r) {
+		return errCrossOrigin
+	}
+	id, err := strconv.ParseUint(r.PostFormValue("id"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid id %q", r.PostFormValue("id"))
+	}
+	req.ID, req.Action = id, r.PostFormValue("action")
+	if req.Action == "resize" {
+		if req.Concurrency, err = strconv.Atoi(r.PostFormValue("concurrency")); err != nil {
+			return fmt.Errorf("invalid concurrency %q", r.PostFormValue("concurrency"))
+		}
+	}
+	return nil
+}
+
+// sameOrigin report whether the Origin header, or the Referer header when there is no Origin, has the host of r.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		origin = r.Header.Get("Referer")
+	}
+	if origin == "" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+func wantJSON(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+type poolsView struct {
+	Pools []poolView `json:"pools"`
+}
+
+type poolView struct {
+	ID          uint64        `json:"id"`
+	Name        string        `json:"name"`
+	State       string        `json:"state"`
+	Concurrency int           `json:"concurrency"`
+	Stats       statsView     `json:"stats"`
+	Running     []runningView `json:"running"`
+	Errors      []errorView   `json:"errors"`
+}
+
+type statsView struct {
+	Queued        int64         `json:"queued"`
+	Running       int64         `json:"running"`
+	IdleWorkers   int64         `json:"idle_workers"`
+	Submitted     int64         `json:"submitted"`
+	Succeeded     int64         `json:"succeeded"`
+	Failed        int64         `json:"failed"`
+	Retried       int64         `json:"retried"`
+	Dropped       int64         `json:"dropped"`
+	Panicked      int64         `json:"panicked"`
+	BusySeconds   float64       `json:"busy_seconds"`
+	QueueWait     histogramView `json:"queue_wait"`
+	AttemptTime   histogramView `json:"attempt_time"`
+	ExecutionTime histogramView `json:"execution_time"`
+}
+
+type histogramView struct {
+	Count      int64   `json:"count"`
+	SumSeconds float64 `json:"sum_seconds"`
+	MaxSeconds float64 `json:"max_seconds"`
+	P50Seconds float64 `json:"p50_seconds"`
+	P90Seconds float64 `json:"p90_seconds"`
+	P99Seconds float64 `json:"p99_seconds"`
+}
+
+type runningView struct {
+	Label          string  `json:"label"`
+	Worker         int     `json:"worker"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+}
+
+type errorView struct {
+	Label string    `json:"label"`
+	Time  time.Time `json:"time"`
+	Error string    `json:"error"`
+}
+
+func newPoolView(info internal.PoolInfo) poolView {
+	s := info.Stats
+	pv := poolView{
+		ID:          info.ID,
+		Name:        info.Name,
+		State:       info.State,
+		Concurrency: info.Concurrency,
+		Stats: statsView{
+			Queued:        s.Queued,
+			Running:       s.Running,
+			IdleWorkers:   s.IdleWorkers,
+			Submitted:     s.Submitted,
+			Succeeded:     s.Succeeded,
+			Failed:        s.Failed,
+			Retried:       s.Retried,
+			Dropped:       s.Dropped,
+			Panicked:      s.Panicked,
+			BusySeconds:   s.BusyTime.Seconds(),
+			QueueWait:     newHistogramView(s.QueueWait),
+			AttemptTime:   newHistogramView(s.AttemptTime),
+			ExecutionTime: newHistogramView(s.ExecutionTime),
+		},
+		Running: make([]runningView, len(info.Running)),
+		Errors:  make([]errorView, len(info.Errors)),
+	}
+	for i, rj := range info.Running {
+		pv.Running[i] = runningView{Label: rj.Label, Worker: rj.Worker, ElapsedSeconds: rj.Elapsed.Seconds()}
+	}
+	for i, je := range info.Errors {
+		pv.Errors[i] = errorView{Label: je.Label, Time: je.Time, Error: je.Err.Error()}
+	}
+	return pv
+}
+
+func newHistogramView(h internal.Histogram) histogramView {
+	return histogramView{
+		Count:      h.Count,
+		SumSeconds: h.Sum.Seconds(),
+		MaxSeconds: h.Max.Seconds(),
+		P50Seconds: h.P50.Seconds(),
+		P90Seconds: h.P90.Seconds(),
+		P99Seconds: h.P99.Seconds(),
+	}
+}
+
+type pageData struct {
+	Path  string
+	Pools []poolView
+}
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>bworker</title></head>
+<body>
+<h1>bworker pools</h1>
+{{- range .Pools}}
+<h2>#{{.ID}} {{if .Name}}{{.Name}}{{else}}(unnamed){{end}}</h2>
+<p>state: {{.State}}, concurrency: {{.Concurrency}}, queued: {{.Stats.Queued}}, running: {{.Stats.Running}},
+idle workers: {{.Stats.IdleWorkers}}, submitted: {{.Stats.Submitted}}, succeeded: {{.Stats.Succeeded}},
+failed: {{.Stats.Failed}}, retried: {{.Stats.Retried}}, dropped: {{.Stats.Dropped}},
+panicked: {{.Stats.Panicked}}</p>
+<form method="post" action="{{$.Path}}">
+<input type="hidden" name="id" value="{{.ID}}">
+<button name="action" value="pause">pause</button>
+<button name="action" value="resume">resume</button>
+<input type="number" name="concurrency" min="1" max="4096" value="{{.Concurrency}}">
+<button name="action" value="resize">resize</button>
+</form>
+<h3>running jobs</h3>
+<table>
+<tr><th>worker</th><th>label</th><th>elapsed (s)</th></tr>
+{{- range .Running}}
+<tr><td>{{.Worker}}</td><td>{{.Label}}</td><td>{{printf "%.3f" .ElapsedSeconds}}</td></tr>
+{{- end}}
+</table>
+<h3>recent errors</h3>
+<table>
+<tr><th>time</th><th>label</th><th>error</th></tr>
+{{- range .Errors}}
+<tr><td>{{.Time.Format "2006-01-02T15:04:05.000Z07:00"}}</td><td>{{.Label}}</td><td>{{.Error}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>no pool is registered</p>
+{{- end}}
+</body>
+</html>
+`))
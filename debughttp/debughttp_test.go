@@ -0,0 +1,137 @@
+package debughttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/bearaujus/bworker/pool"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDebug(t *testing.T) {
+	bwp := pool.NewBWorkerPool(2, pool.WithName("emails"))
+	defer bwp.Shutdown()
+	bwp.Do(func() error {
+		return errors.New("an error")
+	})
+	bwp.Wait()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	bwp.DoHandle(func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}, pool.WithLabel("a"))
+	<-started
+	defer close(release)
+	time.Sleep(time.Millisecond * 100)
+
+	list := func() poolsView {
+		rec := httptest.NewRecorder()
+		NewBWorkerDebug().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/bworker?format=json", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+		var got poolsView
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		return got
+	}
+	do := func(body string, contentType string, origin string, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/debug/bworker", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		NewBWorkerDebug().ServeHTTP(rec, req)
+		return rec
+	}
+	// The host of httptest.NewRequest is example.com
+	post := func(form url.Values, accept string) *httptest.ResponseRecorder {
+		return do(form.Encode(), "application/x-www-form-urlencoded", "http://example.com", accept)
+	}
+
+	got := list()
+	if !assert.Len(t, got.Pools, 1) {
+		return
+	}
+	pv := got.Pools[0]
+	id := pv.ID
+	assert.Equal(t, "emails", pv.Name)
+	assert.Equal(t, "running", pv.State)
+	assert.Equal(t, 2, pv.Concurrency)
+	assert.Equal(t, int64(1), pv.Stats.Failed)
+	assert.Equal(t, int64(1), pv.Stats.Running)
+	if assert.Len(t, pv.Running, 1) {
+		assert.Equal(t, "a", pv.Running[0].Label)
+		assert.LessOrEqual(t, 0.1, pv.Running[0].ElapsedSeconds)
+	}
+	if assert.Len(t, pv.Errors, 1) {
+		assert.Equal(t, "an error", pv.Errors[0].Error)
+	}
+
+	// HTML
+	rec := httptest.NewRecorder()
+	NewBWorkerDebug().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/bworker", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "emails")
+	assert.Contains(t, rec.Body.String(), "an error")
+
+	// Actions
+	sid := strconv.FormatUint(id, 10)
+	rec = post(url.Values{"id": {sid}, "action": {"pause"}}, "application/json")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, bwp.IsPaused())
+	rec = post(url.Values{"id": {sid}, "action": {"resume"}}, "")
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/debug/bworker", rec.Header().Get("Location"))
+	assert.False(t, bwp.IsPaused())
+	rec = post(url.Values{"id": {sid}, "action": {"resize"}, "concurrency": {"3"}}, "application/json")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resized poolView
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resized))
+	assert.Equal(t, id, resized.ID)
+	assert.Equal(t, 3, resized.Concurrency)
+	rec = do(`{"id":`+sid+`,"action":"resize","concurrency":4}`, "application/json", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resized))
+	assert.Equal(t, 4, resized.Concurrency)
+
+	// Invalid requests
+	form := "application/x-www-form-urlencoded"
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		origin      string
+		wantCode    int
+	}{
+		{name: "test invalid id", body: "id=x&action=pause", contentType: form, origin: "http://example.com", wantCode: http.StatusBadRequest},
+		{name: "test unknown id", body: "id=0&action=pause", contentType: form, origin: "http://example.com", wantCode: http.StatusNotFound},
+		{name: "test invalid action", body: "id=" + sid + "&action=stop", contentType: form, origin: "http://example.com", wantCode: http.StatusBadRequest},
+		{name: "test invalid concurrency", body: "id=" + sid + "&action=resize&concurrency=0", contentType: form, origin: "http://example.com", wantCode: http.StatusBadRequest},
+		{name: "test too high concurrency", body: `{"id":` + sid + `,"action":"resize","concurrency":1000000}`, contentType: "application/json", wantCode: http.StatusBadRequest},
+		{name: "test invalid json", body: `{"id":`, contentType: "application/json", wantCode: http.StatusBadRequest},
+		{name: "test form from another origin", body: "id=" + sid + "&action=pause", contentType: form, origin: "http://evil.com", wantCode: http.StatusForbidden},
+		{name: "test form without origin", body: "id=" + sid + "&action=pause", contentType: form, wantCode: http.StatusForbidden},
+		{name: "test text from another origin", body: "id=" + sid + "&action=pause", contentType: "text/plain", origin: "http://evil.com", wantCode: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantCode, do(tt.body, tt.contentType, tt.origin, "").Code)
+		})
+	}
+	assert.False(t, bwp.IsPaused())
+	rec = httptest.NewRecorder()
+	NewBWorkerDebug().ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/debug/bworker", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, 4, list().Pools[0].Concurrency)
+}
@@ -1,7 +1,46 @@
 package flex
 
 import (
+	"context"
 	"github.com/bearaujus/bworker/internal"
+	"time"
+)
+
+// ErrJobTimeout is the error of a job attempt that returns an error after the deadline of WithJobTimeout or WithTimeout.
+var ErrJobTimeout = internal.ErrJobTimeout
+
+// ErrJobPanic is wrapped by the error of a job attempt that panicked. The panic is recovered and the attempt is
+// retried like a failed one.
+var ErrJobPanic = internal.ErrJobPanic
+
+// Tracer is called around the execution of a job when using WithTracer.
+type Tracer = internal.Tracer
+
+// Hooks are called on the lifecycle events of every job when using WithHooks.
+type Hooks = internal.Hooks
+
+// JobInfo describe a job passed to the Hooks.
+type JobInfo = internal.JobInfo
+
+// Stats is a snapshot of the runtime statistics of a BWorkerFlex returned by Stats.
+type Stats = internal.Stats
+
+// Histogram is a snapshot of a distribution of durations reported by Stats, with its p50, p90 and p99.
+type Histogram = internal.Histogram
+
+// JobHandle is returned by DoHandle to cancel a job and to follow its JobStatus.
+type JobHandle = internal.JobHandle
+
+// JobStatus is the state of a job submitted with DoHandle.
+type JobStatus = internal.JobStatus
+
+const (
+	JobQueued    = internal.JobQueued
+	JobRunning   = internal.JobRunning
+	JobRetrying  = internal.JobRetrying
+	JobSucceeded = internal.JobSucceeded
+	JobFailed    = internal.JobFailed
+	JobCancelled = internal.JobCancelled
 )
 
 type BWorkerFlex interface {
@@ -11,9 +50,26 @@ type BWorkerFlex interface {
 	// DoSimple submit a job to be executed by a worker without an error.
 	DoSimple(job func())
 
+	// DoHandle submit a job to be executed by a worker with a context, and return a JobHandle to cancel the job and
+	// to follow its JobStatus. A running job sees its context cancelled. If the job is nil, the returned JobHandle
+	// is already cancelled.
+	DoHandle(job func(ctx context.Context) error, opts ...OptionJob) JobHandle
+
+	// DoAfter submit a job to be executed by a worker after the duration d.
+	// The job is counted by Wait as soon as it is submitted.
+	DoAfter(d time.Duration, job func() error)
+
+	// DoAt submit a job to be executed by a worker at the time t.
+	// The job is counted by Wait as soon as it is submitted.
+	DoAt(t time.Time, job func() error)
+
 	// Wait wait for all jobs to be completed.
 	Wait()
 
+	// Stats return a snapshot of the runtime statistics of the BWorkerFlex, such as the number of queued and running
+	// jobs. IdleWorkers is always 0 since every job is executed by its own worker.
+	Stats() Stats
+
 	// ClearErr reset the error variable when you are using WithErrors.
 	ClearErr()
 
@@ -24,6 +80,9 @@ type BWorkerFlex interface {
 type bWorkerFlex struct {
 	jobManager   *internal.JobManager
 	errorManager *internal.ErrorManager
+	delayer      *internal.Delayer
+	// pctx carries the pprof labels of every job when using WithProfiling, or nil otherwise
+	pctx context.Context
 }
 
 // NewBWorkerFlex create a new BWorkerFlex with OptionFlex(s) and unlimited concurrency level.
@@ -37,18 +96,34 @@ func NewBWorkerFlex(opts ...OptionFlex) BWorkerFlex {
 	}
 	em := internal.NewErrorManager(o.Err, o.Errs)
 	bwf := &bWorkerFlex{
-		jobManager:   internal.NewJobManager(o.Retry, em),
+		jobManager:   internal.NewJobManager(o.OptionJobManager, em),
 		errorManager: em,
 	}
+	bwf.delayer = internal.NewDelayer(func(pendingJob internal.PendingJob) {
+		go bwf.run(pendingJob)
+	})
+	if o.Profiling {
+		bwf.pctx = internal.ProfileLabels(context.Background(), "bworker.worker", "flex")
+	}
 	return bwf
 }
 
+// run execute pendingJob in the current goroutine, with the pprof labels and runtime/trace task when using
+// WithProfiling.
+func (bwf *bWorkerFlex) run(pendingJob internal.PendingJob) {
+	if bwf.pctx == nil {
+		pendingJob()
+		return
+	}
+	internal.Profile(bwf.pctx, "", pendingJob)
+}
+
 func (bwf *bWorkerFlex) Do(job func() error) {
 	if job == nil {
 		return
 	}
 	pendingJob := bwf.jobManager.NewJob(job)
-	go pendingJob()
+	go bwf.run(pendingJob)
 }
 
 func (bwf *bWorkerFlex) DoSimple(job func()) {
@@ -56,13 +131,37 @@ func (bwf *bWorkerFlex) DoSimple(job func()) {
 		return
 	}
 	pendingJob := bwf.jobManager.NewJobSimple(job)
-	go pendingJob()
+	go bwf.run(pendingJob)
+}
+
+func (bwf *bWorkerFlex) DoHandle(job func(ctx context.Context) error, opts ...OptionJob) JobHandle {
+	if job == nil {
+		return internal.NewDroppedJobHandle(nil)
+	}
+	pendingJob, handle := bwf.jobManager.NewJobHandle(job, newOptionJob(opts))
+	go bwf.run(pendingJob)
+	return handle
+}
+
+func (bwf *bWorkerFlex) DoAfter(d time.Duration, job func() error) {
+	bwf.DoAt(time.Now().Add(d), job)
+}
+
+func (bwf *bWorkerFlex) DoAt(t time.Time, job func() error) {
+	if job == nil {
+		return
+	}
+	bwf.delayer.Add(t, bwf.jobManager.NewJobAt(t, job))
 }
 
 func (bwf *bWorkerFlex) Wait() {
 	bwf.jobManager.Wait()
 }
 
+func (bwf *bWorkerFlex) Stats() Stats {
+	return bwf.jobManager.Stats()
+}
+
 func (bwf *bWorkerFlex) ClearErr() {
 	bwf.errorManager.ClearErr()
 }
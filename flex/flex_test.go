@@ -1,13 +1,28 @@
 package flex
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"github.com/stretchr/testify/assert"
+	"runtime/pprof"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestWorkerFlex(t *testing.T) {
+	var (
+		hookMu = &sync.Mutex{}
+		hooked = make(map[string][]JobInfo)
+	)
+	hook := func(event string) func(info JobInfo) {
+		return func(info JobInfo) {
+			hookMu.Lock()
+			defer hookMu.Unlock()
+			hooked[event] = append(hooked[event], info)
+		}
+	}
 	type args struct {
 		opts []OptionFlex
 	}
@@ -22,7 +37,7 @@ func TestWorkerFlex(t *testing.T) {
 		{
 			name: "test use default value",
 			args: args{
-				opts: []OptionFlex{WithRetry(-1), nil},
+				opts: []OptionFlex{WithRetry(-1), WithLogger(nil), nil},
 			},
 			jobs:        nil,
 			wantRet:     0,
@@ -39,6 +54,9 @@ func TestWorkerFlex(t *testing.T) {
 
 				bwf.Do(nil)
 				bwf.DoSimple(nil)
+				bwf.DoAfter(0, nil)
+				bwf.DoAt(time.Now(), nil)
+				assert.Equal(t, JobCancelled, bwf.DoHandle(nil).Status())
 				return &ret
 			},
 			wantRet:     0,
@@ -79,6 +97,237 @@ func TestWorkerFlex(t *testing.T) {
 			wantErr:     true,
 			wantErrsLen: 500,
 		},
+		{
+			name: "test execute delayed jobs",
+			args: args{
+				opts: []OptionFlex{WithError(nil), WithErrors(nil)},
+			},
+			jobs: func(bwf BWorkerFlex) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+				var got []string
+
+				record := func(name string) func() error {
+					return func() error {
+						mu.Lock()
+						defer mu.Unlock()
+						got = append(got, name)
+						ret++
+						return nil
+					}
+				}
+				start := time.Now()
+				bwf.DoAt(start.Add(time.Millisecond*600), record("at"))
+				bwf.DoAfter(time.Millisecond*300, record("after"))
+				bwf.Do(record("now"))
+				// Delayed jobs are counted by Wait. The total executed time should be around ~600 ms
+				bwf.Wait()
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Millisecond*600, ts)
+				assert.LessOrEqual(t, ts, (time.Millisecond*600)+(time.Millisecond*100)) // Add 0.1s as a threshold
+				assert.Equal(t, []string{"now", "after", "at"}, got)
+				// The queue wait of a delayed job starts at its due time
+				queueWait := bwf.Stats().QueueWait
+				assert.Equal(t, int64(3), queueWait.Count)
+				assert.Less(t, queueWait.Max, time.Millisecond*100)
+				return &ret
+			},
+			wantRet:     3,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test execute jobs with handle",
+			args: args{
+				opts: []OptionFlex{WithRetry(1), WithError(nil), WithErrors(nil)},
+			},
+			jobs: func(bwf BWorkerFlex) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				started := make(chan struct{})
+				running := bwf.DoHandle(func(ctx context.Context) error {
+					mu.Lock()
+					ret++
+					mu.Unlock()
+					close(started)
+					<-ctx.Done()
+					return ctx.Err()
+				})
+				<-started
+				assert.Equal(t, JobRunning, running.Status())
+				running.Cancel()
+				<-running.Done()
+				assert.Equal(t, JobCancelled, running.Status())
+
+				failed := bwf.DoHandle(func(ctx context.Context) error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return errors.New("an error")
+				})
+				<-failed.Done()
+				assert.Equal(t, JobFailed, failed.Status())
+				return &ret
+			},
+			wantRet:     1 + (1 + 1), // running + (base attempt + num retry)
+			wantErr:     true,
+			wantErrsLen: 1,
+		},
+		{
+			name: "test hooks",
+			args: args{
+				opts: []OptionFlex{WithRetry(1), WithError(nil), WithErrors(nil), WithHooks(Hooks{
+					OnSubmit:  hook("submit"),
+					OnStart:   hook("start"),
+					OnSuccess: hook("success"),
+					OnFailure: hook("failure"),
+					OnRetry:   hook("retry"),
+				})},
+			},
+			jobs: func(bwf BWorkerFlex) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				bwf.Do(func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return errors.New("an error")
+				})
+				bwf.DoSimple(func() {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+				})
+				bwf.Wait()
+				hookMu.Lock()
+				defer hookMu.Unlock()
+				assert.Len(t, hooked["submit"], 2)
+				assert.Len(t, hooked["start"], 2)
+				assert.Len(t, hooked["retry"], 1)
+				assert.Len(t, hooked["success"], 1)
+				assert.Len(t, hooked["failure"], 1)
+				assert.EqualError(t, hooked["failure"][0].Err, "an error")
+				return &ret
+			},
+			wantRet:     (1 + 1) + 1, // (base attempt + num retry) + succeeded
+			wantErr:     true,
+			wantErrsLen: 1,
+		},
+		{
+			name: "test profiling",
+			args: args{
+				opts: []OptionFlex{WithProfiling()},
+			},
+			jobs: func(bwf BWorkerFlex) *int64 {
+				var ret int64
+
+				bwf.DoSimple(func() {
+					ret++
+					goroutines := &bytes.Buffer{}
+					assert.NoError(t, pprof.Lookup("goroutine").WriteTo(goroutines, 1))
+					assert.Contains(t, goroutines.String(), `# labels: {"bworker.worker":"flex"}`)
+				})
+				bwf.Wait()
+				return &ret
+			},
+			wantRet:     1,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test execute jobs with timeout",
+			args: args{
+				opts: []OptionFlex{WithJobTimeout(time.Millisecond * 200), WithJobTimeout(-1), WithRetry(1), WithError(nil), WithErrors(nil),
+					WithRetryIf(func(err error) bool {
+						return errors.Is(err, ErrJobTimeout)
+					}),
+				},
+			},
+			jobs: func(bwf BWorkerFlex) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				start := time.Now()
+				// The job does not see the deadline, it is waited for and its result is kept
+				bwf.DoSimple(func() {
+					mu.Lock()
+					ret++
+					mu.Unlock()
+					time.Sleep(time.Millisecond * 300)
+				})
+				bwf.Do(func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return errors.New("an error")
+				})
+				handle := bwf.DoHandle(func(ctx context.Context) error {
+					mu.Lock()
+					ret++
+					mu.Unlock()
+					<-ctx.Done()
+					return ctx.Err()
+				}, WithTimeout(time.Millisecond*100), nil)
+				<-handle.Done()
+				assert.ErrorIs(t, handle.Err(), ErrJobTimeout)
+				bwf.Wait()
+				// The total executed time should be around ~300 ms: the jobs are executed concurrently
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Millisecond*300, ts)
+				assert.LessOrEqual(t, ts, (time.Millisecond*300)+(time.Millisecond*100)) // Add 0.1s as a threshold
+				return &ret
+			},
+			wantRet:     1 + 1 + (1 + 1), // not timed out + not retried + (base attempt + num retry)
+			wantErr:     true,
+			wantErrsLen: 2,
+		},
+		{
+			name: "test stats",
+			args: args{
+				opts: []OptionFlex{WithRetry(1), WithError(nil), WithErrors(nil)},
+			},
+			jobs: func(bwf BWorkerFlex) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				assert.Equal(t, Stats{}, bwf.Stats())
+				release := make(chan struct{})
+				started := make(chan struct{})
+				bwf.DoSimple(func() {
+					close(started)
+					<-release
+				})
+				bwf.DoAfter(time.Millisecond*300, func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return nil
+				})
+				<-started
+				stats := bwf.Stats()
+				assert.Equal(t, int64(1), stats.Queued)
+				assert.Equal(t, int64(1), stats.Running)
+				close(release)
+				bwf.Do(func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					panic("a panic")
+				})
+				time.Sleep(time.Millisecond * 100)
+				stats = bwf.Stats()
+				assert.Equal(t, int64(3), stats.AttemptTime.Count)
+				stats.BusyTime = 0
+				stats.QueueWait, stats.AttemptTime, stats.ExecutionTime = Histogram{}, Histogram{}, Histogram{}
+				assert.Equal(t, Stats{Queued: 1, Submitted: 3, Succeeded: 1, Failed: 1, Retried: 1, Panicked: 2}, stats)
+				return &ret
+			},
+			wantRet:     (1 + 1) + 1, // (base attempt + num retry) + delayed job
+			wantErr:     true,
+			wantErrsLen: 1,
+		},
 		{
 			name: "test clear error",
 			args: args{
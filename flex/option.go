@@ -1,12 +1,88 @@
 package flex
 
-import "github.com/bearaujus/bworker/internal"
+import (
+	"context"
+	"github.com/bearaujus/bworker/internal"
+	"log/slog"
+	"time"
+)
 
 type OptionFlex interface {
 	Apply(o *internal.OptionFlex)
 }
 
-// WithRetry set the number of times to retry a failed job.
+// WithJobTimeout set the maximum duration of every attempt of a job. It only takes effect for a job submitted with
+// DoHandle that reads its context: the job runs with a context deadline, and an attempt that returns an error after
+// the deadline fails with ErrJobTimeout, while an attempt that returns nil is succeeded. Jobs submitted with Do and
+// DoSimple do not see the deadline and are not affected. Every job runs in its own goroutine, so a timed out attempt
+// is retried once it returns.
+func WithJobTimeout(d time.Duration) OptionFlex {
+	return &withJobTimeout{d}
+}
+
+type withJobTimeout struct{ d time.Duration }
+
+func (w *withJobTimeout) Apply(o *internal.OptionFlex) {
+	if w.d <= 0 {
+		return
+	}
+	o.JobTimeout = w.d
+}
+
+// WithTracer set a Tracer to be called when a job is submitted, started and completed, and around every attempt of
+// the job. The context of a job submitted with DoHandle and WithContext is passed to the Tracer, so a trace can be
+// continued by the worker. See the tracing package for an adapter creating a span per job and per attempt.
+func WithTracer(t Tracer) OptionFlex {
+	return &withTracer{t}
+}
+
+type withTracer struct{ t Tracer }
+
+func (w *withTracer) Apply(o *internal.OptionFlex) {
+	o.Tracer = w.t
+}
+
+// WithHooks set the Hooks to be called with a JobInfo when a job is submitted, started, retried, completed or dropped.
+// A nil hook is ignored. The hooks are called synchronously, so a slow hook delays the submitter or the worker. A hook
+// may call the BWorkerFlex freely.
+func WithHooks(h Hooks) OptionFlex {
+	return &withHooks{h}
+}
+
+type withHooks struct{ h Hooks }
+
+func (w *withHooks) Apply(o *internal.OptionFlex) {
+	o.Hooks = w.h
+}
+
+// WithLogger set a logger to emit structured records for the retries, failures, panics and drops of the jobs, with
+// the label and the attempt of the job as attributes. A retry is logged with the error of the previous attempt.
+// If you're not using this option, the BWorkerFlex is silent.
+func WithLogger(l *slog.Logger) OptionFlex {
+	return &withLogger{l}
+}
+
+type withLogger struct{ l *slog.Logger }
+
+func (w *withLogger) Apply(o *internal.OptionFlex) {
+	o.Logger = w.l
+}
+
+// WithProfiling set the BWorkerFlex to label the goroutine of every job with the bworker.worker=flex pprof label, and
+// to wrap every job in a runtime/trace task and region named bworker.job, so go tool pprof and go tool trace can
+// attribute the cost to the BWorkerFlex.
+func WithProfiling() OptionFlex {
+	return &withProfiling{}
+}
+
+type withProfiling struct{}
+
+func (w *withProfiling) Apply(o *internal.OptionFlex) {
+	o.Profiling = true
+}
+
+// WithRetry set the number of times to retry a failed job. A job that panics is recovered and fails with an error
+// wrapping ErrJobPanic.
 func WithRetry(n int) OptionFlex {
 	return &withRetry{n}
 }
@@ -20,6 +96,20 @@ func (w *withRetry) Apply(o *internal.OptionFlex) {
 	o.Retry = w.n
 }
 
+// WithRetryIf set a function to decide whether a failed job is retried by its error. If you're not using this option,
+// every error is retried. For example, you can retry timeouts only with:
+//
+//	WithRetryIf(func(err error) bool { return errors.Is(err, ErrJobTimeout) })
+func WithRetryIf(f func(err error) bool) OptionFlex {
+	return &withRetryIf{f}
+}
+
+type withRetryIf struct{ f func(err error) bool }
+
+func (w *withRetryIf) Apply(o *internal.OptionFlex) {
+	o.RetryIf = w.f
+}
+
 // WithError set a pointer to an error variable that will be populated if any job fails.
 func WithError(e *error) OptionFlex {
 	return &withError{e}
@@ -41,3 +131,44 @@ type withErrors struct{ es *[]error }
 func (w *withErrors) Apply(o *internal.OptionFlex) {
 	o.Errs = w.es
 }
+
+type OptionJob interface {
+	Apply(o *internal.OptionJob)
+}
+
+// WithTimeout set the maximum duration of every attempt of a job submitted with DoHandle, overriding WithJobTimeout.
+func WithTimeout(d time.Duration) OptionJob {
+	return &withTimeout{d}
+}
+
+type withTimeout struct{ d time.Duration }
+
+func (w *withTimeout) Apply(o *internal.OptionJob) {
+	if w.d <= 0 {
+		return
+	}
+	o.Timeout = w.d
+}
+
+// WithContext set the context of a job submitted with DoHandle. Its values, such as a trace span, are passed to the
+// Tracer and visible to the job, while its cancellation is not propagated to the job.
+func WithContext(ctx context.Context) OptionJob {
+	return &withContext{ctx}
+}
+
+type withContext struct{ ctx context.Context }
+
+func (w *withContext) Apply(o *internal.OptionJob) {
+	o.Context = w.ctx
+}
+
+func newOptionJob(opts []OptionJob) *internal.OptionJob {
+	o := &internal.OptionJob{}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt.Apply(o)
+	}
+	return o
+}
@@ -0,0 +1,17 @@
+package internal
+
+// Chain is a job submitted with ChainDoer.DoChain.
+type Chain struct {
+	// Job is executed again, including its retries, by the same worker while Next returns true. Next receives the
+	// final error of every execution of Job.
+	Job  func() error
+	Next func(err error) bool
+}
+
+// ChainDoer is implemented by the BWorkerPool created with pool.NewBWorkerPool, so the schedule and dag packages know
+// when a job is completed including its retries. Chain is internal, so DoChain can not be called outside this module.
+type ChainDoer interface {
+	// DoChain queue the Chain like BWorkerPool.Do without waiting for room in the job pool, so the caller is never
+	// blocked by a full or paused pool. It returns false if the pool IsDead.
+	DoChain(c Chain) bool
+}
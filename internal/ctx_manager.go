@@ -9,6 +9,7 @@ type CtxManager struct {
 	c    context.Context
 	cl   context.CancelFunc
 	rwMu *sync.RWMutex
+	wg   *sync.WaitGroup
 }
 
 func (cm *CtxManager) Ctx() context.Context {
@@ -27,6 +28,52 @@ func (cm *CtxManager) Cancel() bool {
 	return true
 }
 
+// IfAlive call f while the context is not cancelled, and Cancel waits for f to return. It returns false without
+// calling f if the context is cancelled.
+func (cm *CtxManager) IfAlive(f func()) bool {
+	cm.rwMu.RLock()
+	defer cm.rwMu.RUnlock()
+	if cm.c.Err() != nil {
+		return false
+	}
+	f()
+	return true
+}
+
+// Enter register a caller while the context is not cancelled, and WaitLeft waits for it to call Leave. Unlike IfAlive,
+// Cancel does not wait for the caller, so the caller can run user code that calls Cancel, such as the hooks of a job.
+// It returns false without registering the caller if the context is cancelled.
+func (cm *CtxManager) Enter() bool {
+	cm.rwMu.RLock()
+	defer cm.rwMu.RUnlock()
+	if cm.c.Err() != nil {
+		return false
+	}
+	cm.wg.Add(1)
+	return true
+}
+
+// Leave unregister a caller registered by Enter.
+func (cm *CtxManager) Leave() {
+	cm.wg.Done()
+}
+
+// WaitLeft wait for every caller registered by Enter to call Leave.
+func (cm *CtxManager) WaitLeft() {
+	cm.wg.Wait()
+}
+
+// Renew replace a cancelled context with a new one. It returns false if the context is not cancelled.
+func (cm *CtxManager) Renew() bool {
+	cm.rwMu.Lock()
+	defer cm.rwMu.Unlock()
+	if cm.c.Err() == nil {
+		return false
+	}
+	cm.c, cm.cl = context.WithCancel(context.Background())
+	return true
+}
+
 func (cm *CtxManager) IsDead() bool {
 	cm.rwMu.RLock()
 	defer cm.rwMu.RUnlock()
@@ -39,5 +86,6 @@ func NewCtxManager() *CtxManager {
 		c:    c,
 		cl:   cl,
 		rwMu: &sync.RWMutex{},
+		wg:   &sync.WaitGroup{},
 	}
 }
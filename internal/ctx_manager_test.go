@@ -3,6 +3,7 @@ package internal
 import (
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestCtxManager(t *testing.T) {
@@ -16,12 +17,47 @@ func TestCtxManager(t *testing.T) {
 				assert.Nil(t, cm.Ctx().Err())
 				assert.False(t, cm.IsDead())
 
+				var called int
+				assert.True(t, cm.IfAlive(func() { called++ }))
+				assert.Equal(t, 1, called)
+
 				assert.True(t, cm.Cancel())
 
+				assert.False(t, cm.IfAlive(func() { called++ }))
+				assert.Equal(t, 1, called)
+
 				assert.NotNil(t, cm.Ctx().Err())
 				assert.True(t, cm.IsDead())
 
 				assert.False(t, cm.Cancel())
+
+				assert.True(t, cm.Renew())
+
+				assert.Nil(t, cm.Ctx().Err())
+				assert.False(t, cm.IsDead())
+
+				assert.False(t, cm.Renew())
+			},
+		},
+		{
+			name: "test enter and leave",
+			runner: func(cm *CtxManager) {
+				assert.True(t, cm.Enter())
+				// Cancel does not wait for the caller to leave, while WaitLeft does
+				assert.True(t, cm.Cancel())
+				assert.False(t, cm.Enter())
+				left := make(chan struct{})
+				go func() {
+					cm.WaitLeft()
+					close(left)
+				}()
+				select {
+				case <-left:
+					t.Error("WaitLeft returned before Leave")
+				case <-time.After(time.Millisecond * 100):
+				}
+				cm.Leave()
+				<-left
 			},
 		},
 	}
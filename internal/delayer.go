@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// Delayer hold PendingJob(s) until their scheduled time and then hand them to the dispatch function.
+//
+// All the delayed jobs share a single goroutine and a single timer. The goroutine is only alive while there are
+// delayed jobs, so an idle Delayer does not need to be stopped.
+type Delayer struct {
+	mu       *sync.Mutex
+	dispatch func(job PendingJob)
+	queue    *delayedJobs
+	seq      uint64
+	// wake interrupt the running goroutine when an earlier job is added or the Delayer is stopped.
+	wake    chan struct{}
+	running bool
+	stopped bool
+}
+
+type delayedJob struct {
+	job PendingJob
+	at  time.Time
+	seq uint64
+}
+
+type delayedJobs []*delayedJob
+
+func (dj delayedJobs) Len() int { return len(dj) }
+
+func (dj delayedJobs) Less(i, j int) bool {
+	if !dj[i].at.Equal(dj[j].at) {
+		return dj[i].at.Before(dj[j].at)
+	}
+	return dj[i].seq < dj[j].seq
+}
+
+func (dj delayedJobs) Swap(i, j int) { dj[i], dj[j] = dj[j], dj[i] }
+
+func (dj *delayedJobs) Push(x interface{}) { *dj = append(*dj, x.(*delayedJob)) }
+
+func (dj *delayedJobs) Pop() interface{} {
+	old := *dj
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*dj = old[:n-1]
+	return x
+}
+
+// Add schedule the job to be dispatched at the given time. It returns false if the Delayer is stopped.
+func (d *Delayer) Add(at time.Time, job PendingJob) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		return false
+	}
+	d.seq++
+	dj := &delayedJob{job: job, at: at, seq: d.seq}
+	heap.Push(d.queue, dj)
+	if !d.running {
+		d.running = true
+		go d.run()
+		return true
+	}
+	if (*d.queue)[0] == dj {
+		d.notify()
+	}
+	return true
+}
+
+// Len return the number of jobs waiting for their scheduled time.
+func (d *Delayer) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.queue.Len()
+}
+
+// Stop stop dispatching jobs and return the jobs that were never dispatched. Add will return false afterwards.
+func (d *Delayer) Stop() []PendingJob {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		return nil
+	}
+	d.stopped = true
+	jobs := make([]PendingJob, 0, d.queue.Len())
+	for _, dj := range *d.queue {
+		jobs = append(jobs, dj.job)
+	}
+	*d.queue = nil
+	d.notify()
+	return jobs
+}
+
+// Reset accept new jobs again after Stop.
+func (d *Delayer) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stopped = false
+}
+
+func (d *Delayer) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Delayer) run() {
+	for {
+		d.mu.Lock()
+		if d.stopped || d.queue.Len() == 0 {
+			d.running = false
+			d.mu.Unlock()
+			return
+		}
+		next := (*d.queue)[0]
+		wait := time.Until(next.at)
+		if wait <= 0 {
+			heap.Pop(d.queue)
+			d.mu.Unlock()
+			d.dispatch(next.job)
+			continue
+		}
+		d.mu.Unlock()
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-d.wake:
+			t.Stop()
+		}
+	}
+}
+
+func NewDelayer(dispatch func(job PendingJob)) *Delayer {
+	return &Delayer{
+		mu:       &sync.Mutex{},
+		dispatch: dispatch,
+		queue:    &delayedJobs{},
+		wake:     make(chan struct{}, 1),
+	}
+}
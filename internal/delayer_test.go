@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDelayer(t *testing.T) {
+	tests := []struct {
+		name   string
+		runner func(d *Delayer, record func(id int) PendingJob, dispatched func() []int)
+	}{
+		{
+			name: "test dispatch by scheduled time",
+			runner: func(d *Delayer, record func(id int) PendingJob, dispatched func() []int) {
+				now := time.Now()
+				assert.True(t, d.Add(now.Add(time.Millisecond*300), record(1)))
+				assert.True(t, d.Add(now.Add(time.Millisecond*100), record(2)))
+				assert.True(t, d.Add(now.Add(time.Millisecond*200), record(3)))
+				assert.True(t, d.Add(now.Add(-time.Second), record(4)))
+				assert.Eventually(t, func() bool { return len(dispatched()) == 4 }, time.Second, time.Millisecond*10)
+				assert.Equal(t, 0, d.Len())
+				assert.Equal(t, []int{4, 2, 3, 1}, dispatched())
+			},
+		},
+		{
+			name: "test add after idle",
+			runner: func(d *Delayer, record func(id int) PendingJob, dispatched func() []int) {
+				assert.True(t, d.Add(time.Now(), record(1)))
+				assert.Eventually(t, func() bool { return len(dispatched()) == 1 }, time.Second, time.Millisecond*10)
+				time.Sleep(time.Millisecond * 100)
+				assert.True(t, d.Add(time.Now(), record(2)))
+				assert.Eventually(t, func() bool { return len(dispatched()) == 2 }, time.Second, time.Millisecond*10)
+			},
+		},
+		{
+			name: "test stop",
+			runner: func(d *Delayer, record func(id int) PendingJob, dispatched func() []int) {
+				assert.True(t, d.Add(time.Now().Add(time.Hour), record(1)))
+				assert.True(t, d.Add(time.Now().Add(time.Hour), record(2)))
+				assert.Equal(t, 2, d.Len())
+				assert.Len(t, d.Stop(), 2)
+				assert.Nil(t, d.Stop())
+				assert.False(t, d.Add(time.Now(), record(3)))
+				assert.Equal(t, 0, d.Len())
+				assert.Empty(t, dispatched())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu  = &sync.Mutex{}
+				got []int
+			)
+			d := NewDelayer(func(job PendingJob) {
+				job()
+			})
+			defer d.Stop()
+			if tt.runner != nil {
+				tt.runner(d, func(id int) PendingJob {
+					return func() {
+						mu.Lock()
+						defer mu.Unlock()
+						got = append(got, id)
+					}
+				}, func() []int {
+					mu.Lock()
+					defer mu.Unlock()
+					return append([]int(nil), got...)
+				})
+			}
+		})
+	}
+}
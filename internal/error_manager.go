@@ -44,6 +44,29 @@ func (em *ErrorManager) ClearErrs() {
 	}
 }
 
+// Err return the error variable, or nil if it is not set.
+func (em *ErrorManager) Err() error {
+	if em == nil || em.e == nil {
+		return nil
+	}
+	em.mu.Lock()
+	defer em.mu.Unlock()
+	return *em.e
+}
+
+// Errs return a copy of the slice of error variables, or nil if it is not set.
+func (em *ErrorManager) Errs() []error {
+	if em == nil || em.es == nil {
+		return nil
+	}
+	em.mu.Lock()
+	defer em.mu.Unlock()
+	if *em.es == nil {
+		return nil
+	}
+	return append([]error(nil), *em.es...)
+}
+
 func NewErrorManager(err *error, errs *[]error) *ErrorManager {
 	if err == nil && errs == nil {
 		return nil
@@ -164,13 +164,17 @@ func TestErrorManager(t *testing.T) {
 				} else {
 					assert.NoError(t, *tt.args.e)
 				}
+				assert.Equal(t, *tt.args.e, em.Err())
 			} else {
 				assert.Nil(t, tt.args.e)
+				assert.Nil(t, em.Err())
 			}
 			if tt.args.es != nil {
 				assert.Equal(t, tt.wantErrsLen, len(*tt.args.es))
+				assert.Equal(t, tt.wantErrsLen, len(em.Errs()))
 			} else {
 				assert.Nil(t, tt.args.es)
+				assert.Nil(t, em.Errs())
 			}
 		})
 	}
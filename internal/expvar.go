@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"expvar"
+	"sync"
+)
+
+// expvars hold the variables published by PublishExpvar. A variable can not be removed from the expvar package, so it
+// is published once and reads the function of its current publisher.
+var expvars = struct {
+	mu   *sync.Mutex
+	vars map[string]*expvarValue
+}{
+	mu:   &sync.Mutex{},
+	vars: make(map[string]*expvarValue),
+}
+
+type expvarValue struct {
+	f func() interface{}
+	// held indicates the variable is published by a publisher that does not call ReleaseExpvar yet.
+	held bool
+}
+
+// PublishExpvar publish the value returned by f under name with the expvar package, so it is served as JSON on
+// /debug/vars. It returns false without publishing if the name is already published by another package, or by another
+// publisher that does not call ReleaseExpvar yet.
+func PublishExpvar(name string, f func() interface{}) bool {
+	expvars.mu.Lock()
+	defer expvars.mu.Unlock()
+	if v, ok := expvars.vars[name]; ok {
+		if v.held {
+			return false
+		}
+		v.f, v.held = f, true
+		return true
+	}
+	if expvar.Get(name) != nil {
+		return false
+	}
+	v := &expvarValue{f: f, held: true}
+	expvars.vars[name] = v
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		expvars.mu.Lock()
+		f := v.f
+		expvars.mu.Unlock()
+		return f()
+	}))
+	return true
+}
+
+// ReleaseExpvar release name published by PublishExpvar, so it can be published again. The variable keeps the last
+// value instead of calling the function of the publisher, so the publisher is not referenced anymore.
+func ReleaseExpvar(name string, last interface{}) {
+	expvars.mu.Lock()
+	defer expvars.mu.Unlock()
+	if v, ok := expvars.vars[name]; ok {
+		v.f = func() interface{} { return last }
+		v.held = false
+	}
+}
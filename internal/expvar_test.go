@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"expvar"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+)
+
+// expvarSeq makes the names published by the tests unique, since the expvar package can not remove a variable and the
+// tests may run more than once with -count.
+var expvarSeq int64
+
+func TestPublishExpvar(t *testing.T) {
+	tests := []struct {
+		name      string
+		varName   string
+		runner    func(name string) bool
+		wantOk    bool
+		wantValue string
+	}{
+		{
+			name:    "test publish",
+			varName: "test_publish",
+			runner: func(name string) bool {
+				return PublishExpvar(name, func() interface{} { return 1 })
+			},
+			wantOk:    true,
+			wantValue: "1",
+		},
+		{
+			name:    "test publish a name that is not released",
+			varName: "test_publish_not_released",
+			runner: func(name string) bool {
+				PublishExpvar(name, func() interface{} { return 1 })
+				return PublishExpvar(name, func() interface{} { return 2 })
+			},
+			wantOk:    false,
+			wantValue: "1",
+		},
+		{
+			name:    "test release keeps the last value",
+			varName: "test_release",
+			runner: func(name string) bool {
+				PublishExpvar(name, func() interface{} { return 1 })
+				ReleaseExpvar(name, Stats{Queued: 2})
+				return true
+			},
+			wantOk:    true,
+			wantValue: `{"Queued":2,`,
+		},
+		{
+			name:    "test publish again after release replaces the value",
+			varName: "test_publish_again",
+			runner: func(name string) bool {
+				PublishExpvar(name, func() interface{} { return 1 })
+				ReleaseExpvar(name, 1)
+				return PublishExpvar(name, func() interface{} { return 3 })
+			},
+			wantOk:    true,
+			wantValue: "3",
+		},
+		{
+			name:    "test publish name of another package",
+			varName: "test_publish_name_of_another_package",
+			runner: func(name string) bool {
+				expvar.NewInt(name).Set(3)
+				return PublishExpvar(name, func() interface{} { return 1 })
+			},
+			wantOk:    false,
+			wantValue: "3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := fmt.Sprintf("%s_%d", tt.varName, atomic.AddInt64(&expvarSeq, 1))
+			assert.Equal(t, tt.wantOk, tt.runner(name))
+			assert.Contains(t, expvar.Get(name).String(), tt.wantValue)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// Histogram is a snapshot of a distribution of durations. The percentiles are approximated by exponential buckets
+// with 8 linear sub-buckets each, so they are at most 12.5% higher than the exact value and never higher than Max.
+type Histogram struct {
+	Count int64
+	Sum   time.Duration
+	Max   time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+}
+
+const (
+	// histogramSubBits is the number of bits of the linear sub-buckets of every power of two
+	histogramSubBits    = 3
+	histogramSubBuckets = 1 << histogramSubBits
+	histogramBuckets    = (64 - histogramSubBits + 1) * histogramSubBuckets
+)
+
+// histogram record durations into exponential buckets with atomic operations only. It is always embedded at
+// a 64-bit aligned offset, since all of its fields are int64.
+type histogram struct {
+	count   int64
+	sum     int64
+	max     int64
+	buckets [histogramBuckets]int64
+}
+
+func (h *histogram) record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	atomic.AddInt64(&h.buckets[bucketOf(uint64(d))], 1)
+	atomic.AddInt64(&h.sum, int64(d))
+	atomic.AddInt64(&h.count, 1)
+	for {
+		m := atomic.LoadInt64(&h.max)
+		if int64(d) <= m || atomic.CompareAndSwapInt64(&h.max, m, int64(d)) {
+			return
+		}
+	}
+}
+
+func (h *histogram) snapshot() Histogram {
+	var (
+		buckets [histogramBuckets]int64
+		total   int64
+	)
+	for i := range buckets {
+		buckets[i] = atomic.LoadInt64(&h.buckets[i])
+		total += buckets[i]
+	}
+	s := Histogram{
+		Count: atomic.LoadInt64(&h.count),
+		Sum:   time.Duration(atomic.LoadInt64(&h.sum)),
+		Max:   time.Duration(atomic.LoadInt64(&h.max)),
+	}
+	quantile := func(q float64) time.Duration {
+		if total == 0 {
+			return 0
+		}
+		rank := int64(math.Ceil(q * float64(total)))
+		var cum int64
+		for i, n := range buckets {
+			if cum += n; cum >= rank {
+				if v := time.Duration(bucketMax(i)); v < s.Max {
+					return v
+				}
+				return s.Max
+			}
+		}
+		return s.Max
+	}
+	s.P50, s.P90, s.P99 = quantile(0.5), quantile(0.9), quantile(0.99)
+	return s
+}
+
+// bucketOf return the index of the bucket of v. Values below histogramSubBuckets have their own bucket, and every
+// following power of two is split into histogramSubBuckets linear sub-buckets.
+func bucketOf(v uint64) int {
+	if v < histogramSubBuckets {
+		return int(v)
+	}
+	exp := bits.Len64(v) - 1
+	sub := int(v>>(exp-histogramSubBits)) - histogramSubBuckets
+	return (exp-histogramSubBits+1)*histogramSubBuckets + sub
+}
+
+// bucketMax return the highest value of the bucket i.
+func bucketMax(i int) uint64 {
+	if i < histogramSubBuckets {
+		return uint64(i)
+	}
+	exp := i/histogramSubBuckets + histogramSubBits - 1
+	sub := uint64(i % histogramSubBuckets)
+	width := uint64(1) << (exp - histogramSubBits)
+	return (histogramSubBuckets+sub)*width + width - 1
+}
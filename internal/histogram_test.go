@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []time.Duration
+		want   Histogram
+	}{
+		{
+			name:   "test without value",
+			values: nil,
+			want:   Histogram{},
+		},
+		{
+			name:   "test with exact small values",
+			values: []time.Duration{0, 1, 2, 3, 4, 5, 6, 7, 7, 7},
+			want:   Histogram{Count: 10, Sum: 42, Max: 7, P50: 4, P90: 7, P99: 7},
+		},
+		{
+			name:   "test with negative value",
+			values: []time.Duration{-1},
+			want:   Histogram{Count: 1, Sum: 0, Max: 0, P50: 0, P90: 0, P99: 0},
+		},
+		{
+			name: "test percentiles are bounded by max",
+			values: func() []time.Duration {
+				var values []time.Duration
+				for i := 0; i < 90; i++ {
+					values = append(values, time.Millisecond)
+				}
+				for i := 0; i < 10; i++ {
+					values = append(values, time.Second)
+				}
+				return values
+			}(),
+			want: Histogram{Count: 100, Sum: (90 * time.Millisecond) + (10 * time.Second), Max: time.Second,
+				P50: bucketMaxDuration(time.Millisecond), P90: bucketMaxDuration(time.Millisecond), P99: time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &histogram{}
+			for _, v := range tt.values {
+				h.record(v)
+			}
+			assert.Equal(t, tt.want, h.snapshot())
+		})
+	}
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	for _, v := range []uint64{0, 1, 7, 8, 9, 15, 16, 17, 1000, 123456789, 1 << 40, 1<<62 + 12345} {
+		i := bucketOf(v)
+		assert.LessOrEqual(t, v, bucketMax(i))
+		// The bucket is at most 12.5% wider than its values
+		assert.LessOrEqual(t, float64(bucketMax(i)-v), float64(v)/histogramSubBuckets)
+		if i > 0 {
+			assert.Less(t, bucketMax(i-1), v)
+		}
+	}
+}
+
+func bucketMaxDuration(d time.Duration) time.Duration {
+	return time.Duration(bucketMax(bucketOf(uint64(d))))
+}
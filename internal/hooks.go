@@ -0,0 +1,71 @@
+package internal
+
+import "time"
+
+// JobInfo describe a job passed to the Hooks.
+type JobInfo struct {
+	// Label is the label of the job, it is only set for a job submitted with DoHandle and WithLabel.
+	Label string
+	// Attempt is the attempt of the job, the first attempt is 0. OnRetry receives the attempt to be executed, and
+	// OnSuccess and OnFailure receive the last attempt.
+	Attempt int
+	// Err is the error of the job for OnFailure, the error of the previous attempt for OnRetry, and the reason the
+	// job is dropped for OnDrop.
+	Err error
+	// Submitted is the time the job is submitted.
+	Submitted time.Time
+	// QueueWait is the time from the submission of the job, or the due time of a delayed job, to its start, once the
+	// job is started.
+	QueueWait time.Duration
+	// Elapsed is the time since the start of the job for OnRetry, OnSuccess and OnFailure.
+	Elapsed time.Duration
+}
+
+// Hooks are called on the lifecycle events of every job. A nil hook is ignored. The hooks are called synchronously
+// by the submitter or the worker, so a slow hook delays the job. The hooks are not called under a lock of the pool, so
+// they may call the pool, except Shutdown and Restart which wait for the job being submitted or executed.
+type Hooks struct {
+	// OnSubmit is called when a job is accepted. Every execution of a recurring job is submitted again.
+	OnSubmit func(info JobInfo)
+	// OnStart is called when a worker starts the job.
+	OnStart func(info JobInfo)
+	// OnSuccess is called when the job is completed without an error.
+	OnSuccess func(info JobInfo)
+	// OnFailure is called when the job still returned an error after its retries, or was cancelled while running.
+	OnFailure func(info JobInfo)
+	// OnRetry is called before a failed attempt is executed again.
+	OnRetry func(info JobInfo)
+	// OnDrop is called when an accepted job will never be executed, because the worker is shut down or the job is
+	// cancelled before it is started.
+	OnDrop func(info JobInfo)
+}
+
+// jobState is the lifecycle of a job reported to the Hooks and Stats.
+type jobState struct {
+	label     string
+	submitted time.Time
+	// queued is the time the job starts waiting for a worker: the submission, or the due time of a delayed job.
+	queued  time.Time
+	begin   time.Time
+	attempt int
+}
+
+func (s *jobState) info(err error) JobInfo {
+	info := JobInfo{
+		Label:     s.label,
+		Attempt:   s.attempt,
+		Err:       err,
+		Submitted: s.submitted,
+	}
+	if !s.begin.IsZero() {
+		info.QueueWait = s.begin.Sub(s.queued)
+		info.Elapsed = time.Since(s.begin)
+	}
+	return info
+}
+
+func call(hook func(info JobInfo), s *jobState, err error) {
+	if hook != nil {
+		hook(s.info(err))
+	}
+}
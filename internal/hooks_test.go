@@ -0,0 +1,152 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+type recordHooks struct {
+	mu     *sync.Mutex
+	events []string
+}
+
+func (r *recordHooks) hook(event string) func(info JobInfo) {
+	return func(info JobInfo) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.events = append(r.events, fmt.Sprintf("%s %s %d %v", event, info.Label, info.Attempt, info.Err))
+	}
+}
+
+func (r *recordHooks) hooks() Hooks {
+	return Hooks{
+		OnSubmit:  r.hook("submit"),
+		OnStart:   r.hook("start"),
+		OnSuccess: r.hook("success"),
+		OnFailure: r.hook("failure"),
+		OnRetry:   r.hook("retry"),
+		OnDrop:    r.hook("drop"),
+	}
+}
+
+func TestHooks(t *testing.T) {
+	tests := []struct {
+		name       string
+		runner     func(jm *JobManager)
+		wantEvents []string
+	}{
+		{
+			name: "test job with retry",
+			runner: func(jm *JobManager) {
+				var attempts int
+				jm.NewJob(func() error {
+					if attempts++; attempts == 1 {
+						return errors.New("an error")
+					}
+					return nil
+				})()
+			},
+			wantEvents: []string{
+				"submit  0 <nil>",
+				"start  0 <nil>",
+				"retry  1 an error",
+				"success  1 <nil>",
+			},
+		},
+		{
+			name: "test failed job",
+			runner: func(jm *JobManager) {
+				jm.NewJob(func() error {
+					return errors.New("an error")
+				})()
+			},
+			wantEvents: []string{
+				"submit  0 <nil>",
+				"start  0 <nil>",
+				"retry  1 an error",
+				"failure  1 an error",
+			},
+		},
+		{
+			name: "test job chain",
+			runner: func(jm *JobManager) {
+				var n int
+				jm.NewJobChain(func() error {
+					return nil
+				}, func(error) bool {
+					n++
+					return n < 2
+				})()
+			},
+			wantEvents: []string{
+				"submit  0 <nil>",
+				"start  0 <nil>",
+				"success  0 <nil>",
+				"submit  0 <nil>",
+				"start  0 <nil>",
+				"success  0 <nil>",
+			},
+		},
+		{
+			name: "test job handle with label",
+			runner: func(jm *JobManager) {
+				pendingJob, _ := jm.NewJobHandle(func(ctx context.Context) error {
+					return nil
+				}, &OptionJob{Label: "a"})
+				pendingJob()
+			},
+			wantEvents: []string{
+				"submit a 0 <nil>",
+				"start a 0 <nil>",
+				"success a 0 <nil>",
+			},
+		},
+		{
+			name: "test job handle cancelled before executed",
+			runner: func(jm *JobManager) {
+				pendingJob, jh := jm.NewJobHandle(func(ctx context.Context) error { return nil }, &OptionJob{Label: "a"})
+				jh.Cancel()
+				pendingJob()
+			},
+			wantEvents: []string{
+				"submit a 0 <nil>",
+				"drop a 0 context canceled",
+			},
+		},
+		{
+			name: "test dropped job handle",
+			runner: func(jm *JobManager) {
+				_, jh := jm.NewJobHandle(func(ctx context.Context) error { return nil }, &OptionJob{Label: "a"})
+				jm.Drop(jh, errors.New("closed"))
+			},
+			wantEvents: []string{
+				"submit a 0 <nil>",
+				"drop a 0 closed",
+			},
+		},
+		{
+			name: "test dropped job",
+			runner: func(jm *JobManager) {
+				jm.NewJobSimple(func() {})
+				jm.Done(errors.New("closed"))
+			},
+			wantEvents: []string{
+				"submit  0 <nil>",
+				"drop  0 closed",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := &recordHooks{mu: &sync.Mutex{}}
+			jm := NewJobManager(OptionJobManager{Retry: 1, Hooks: hooks.hooks()}, NewErrorManager(nil, nil))
+			tt.runner(jm)
+			jm.Wait()
+			assert.Equal(t, tt.wantEvents, hooks.events)
+		})
+	}
+}
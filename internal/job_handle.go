@@ -0,0 +1,147 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// JobStatus is the state of a job submitted with a JobHandle.
+type JobStatus int
+
+const (
+	// JobQueued indicates the job is waiting for a worker.
+	JobQueued JobStatus = iota
+	// JobRunning indicates the job is being executed for the first time.
+	JobRunning
+	// JobRetrying indicates the job failed and is being executed again.
+	JobRetrying
+	// JobSucceeded indicates the job returned no error.
+	JobSucceeded
+	// JobFailed indicates the job still returned an error after its retries.
+	JobFailed
+	// JobCancelled indicates the job was cancelled before it is completed, or dropped before it is executed.
+	JobCancelled
+)
+
+func (s JobStatus) String() string {
+	switch s {
+	case JobQueued:
+		return "queued"
+	case JobRunning:
+		return "running"
+	case JobRetrying:
+		return "retrying"
+	case JobSucceeded:
+		return "succeeded"
+	case JobFailed:
+		return "failed"
+	case JobCancelled:
+		return "cancelled"
+	}
+	return fmt.Sprintf("JobStatus(%d)", int(s))
+}
+
+type JobHandle interface {
+	// Cancel cancel the job. A queued job is skipped by the worker, and a running job sees its context cancelled.
+	// If the job is already completed this function will perform no-op.
+	Cancel()
+
+	// Status return the current JobStatus of the job.
+	Status() JobStatus
+
+	// Done return a channel that is closed when the job is completed, failed or cancelled.
+	Done() <-chan struct{}
+
+	// Err return the error of the job when it failed or it was cancelled while running, or nil otherwise.
+	Err() error
+}
+
+type jobHandle struct {
+	label  string
+	mu     *sync.Mutex
+	status JobStatus
+	err    error
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (jh *jobHandle) Cancel() {
+	jh.mu.Lock()
+	defer jh.mu.Unlock()
+	if jh.status >= JobSucceeded {
+		return
+	}
+	jh.cancel()
+	if jh.status == JobQueued {
+		jh.finishLocked(JobCancelled, nil)
+	}
+}
+
+func (jh *jobHandle) Status() JobStatus {
+	jh.mu.Lock()
+	defer jh.mu.Unlock()
+	return jh.status
+}
+
+func (jh *jobHandle) Done() <-chan struct{} {
+	return jh.done
+}
+
+func (jh *jobHandle) Err() error {
+	jh.mu.Lock()
+	defer jh.mu.Unlock()
+	return jh.err
+}
+
+// start mark the job as running, or retrying when it is not the first attempt. It returns false if the job is
+// cancelled and must be skipped.
+func (jh *jobHandle) start(attempt int) bool {
+	jh.mu.Lock()
+	defer jh.mu.Unlock()
+	if jh.ctx.Err() != nil {
+		jh.finishLocked(JobCancelled, nil)
+		return false
+	}
+	jh.status = JobRunning
+	if attempt != 0 {
+		jh.status = JobRetrying
+	}
+	return true
+}
+
+// finish record the final status of the job. It is a no-op when the job is already completed.
+func (jh *jobHandle) finish(status JobStatus, err error) {
+	jh.mu.Lock()
+	defer jh.mu.Unlock()
+	jh.finishLocked(status, err)
+}
+
+func (jh *jobHandle) finishLocked(status JobStatus, err error) {
+	if jh.status >= JobSucceeded {
+		return
+	}
+	jh.status = status
+	jh.err = err
+	jh.cancel()
+	close(jh.done)
+}
+
+// NewDroppedJobHandle create a JobHandle of a job that is never executed, cancelled with err.
+func NewDroppedJobHandle(err error) JobHandle {
+	jh := newJobHandle()
+	jh.finish(JobCancelled, err)
+	return jh
+}
+
+func newJobHandle() *jobHandle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &jobHandle{
+		mu:     &sync.Mutex{},
+		status: JobQueued,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+}
@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestJobHandle(t *testing.T) {
+	type args struct {
+		numJobRetry int
+		errs        []error
+		cancel      bool
+	}
+	tests := []struct {
+		name         string
+		args         args
+		wantStatuses []JobStatus
+		wantStatus   JobStatus
+		wantErr      bool
+		wantErrsLen  int
+	}{
+		{
+			name: "test succeeded",
+			args: args{
+				numJobRetry: 0,
+				errs:        []error{nil},
+			},
+			wantStatuses: []JobStatus{JobRunning},
+			wantStatus:   JobSucceeded,
+			wantErr:      false,
+			wantErrsLen:  0,
+		},
+		{
+			name: "test succeeded after retry",
+			args: args{
+				numJobRetry: 2,
+				errs:        []error{errors.New("1"), nil},
+			},
+			wantStatuses: []JobStatus{JobRunning, JobRetrying},
+			wantStatus:   JobSucceeded,
+			wantErr:      false,
+			wantErrsLen:  0,
+		},
+		{
+			name: "test failed after retry",
+			args: args{
+				numJobRetry: 1,
+				errs:        []error{errors.New("1"), errors.New("2")},
+			},
+			wantStatuses: []JobStatus{JobRunning, JobRetrying},
+			wantStatus:   JobFailed,
+			wantErr:      true,
+			wantErrsLen:  1,
+		},
+		{
+			name: "test cancelled before executed",
+			args: args{
+				numJobRetry: 1,
+				cancel:      true,
+			},
+			wantStatuses: nil,
+			wantStatus:   JobCancelled,
+			wantErr:      false,
+			wantErrsLen:  0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				err  error
+				errs []error
+			)
+			jm := NewJobManager(OptionJobManager{Retry: tt.args.numJobRetry}, NewErrorManager(&err, &errs))
+			var jh JobHandle
+			var gotStatuses []JobStatus
+			pendingJob, jh := jm.NewJobHandle(func(ctx context.Context) error {
+				gotStatuses = append(gotStatuses, jh.Status())
+				return tt.args.errs[len(gotStatuses)-1]
+			}, &OptionJob{})
+			assert.Equal(t, JobQueued, jh.Status())
+			if tt.args.cancel {
+				jh.Cancel()
+			}
+			go pendingJob()
+			jm.Wait()
+			<-jh.Done()
+			assert.Equal(t, tt.wantStatuses, gotStatuses)
+			assert.Equal(t, tt.wantStatus, jh.Status())
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, err, jh.Err())
+			} else {
+				assert.NoError(t, err)
+				assert.NoError(t, jh.Err())
+			}
+			assert.Equal(t, tt.wantErrsLen, len(errs))
+		})
+	}
+}
+
+func TestDroppedJobHandle(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{
+			name: "test dropped without an error",
+			err:  nil,
+		},
+		{
+			name: "test dropped with an error",
+			err:  errors.New("an error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jh := NewDroppedJobHandle(tt.err)
+			<-jh.Done()
+			assert.Equal(t, JobCancelled, jh.Status())
+			assert.Equal(t, tt.err, jh.Err())
+			jh.Cancel()
+			assert.Equal(t, JobCancelled, jh.Status())
+		})
+	}
+}
+
+func TestJobStatus(t *testing.T) {
+	assert.Equal(t, "queued", JobQueued.String())
+	assert.Equal(t, "running", JobRunning.String())
+	assert.Equal(t, "retrying", JobRetrying.String())
+	assert.Equal(t, "succeeded", JobSucceeded.String())
+	assert.Equal(t, "failed", JobFailed.String())
+	assert.Equal(t, "cancelled", JobCancelled.String())
+	assert.Equal(t, "JobStatus(10)", JobStatus(10).String())
+}
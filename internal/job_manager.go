@@ -1,30 +1,204 @@
 package internal
 
-import "sync"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrJobTimeout is the error of a job attempt that returns an error after its timeout.
+	ErrJobTimeout = errors.New("job timeout")
+
+	// ErrJobPanic is wrapped by the error of a job attempt that panicked.
+	ErrJobPanic = errors.New("job panic")
+)
 
 type JobManager struct {
-	wg  *sync.WaitGroup
-	njr int
-	em  *ErrorManager
+	wg      *sync.WaitGroup
+	c       *counters
+	recent  *recentErrors
+	njr     int
+	timeout time.Duration
+	retryIf func(err error) bool
+	tracer  Tracer
+	hooks   Hooks
+	logger  *slog.Logger
+	em      *ErrorManager
 }
 
 type PendingJob func()
 
 func (jm *JobManager) NewJob(job func() error) PendingJob {
-	jm.wg.Add(1)
+	return jm.NewJobAt(time.Time{}, job)
+}
+
+// NewJobAt create a PendingJob like NewJob for a job that is delayed until t, so its queue wait starts at t instead
+// of its submission.
+func (jm *JobManager) NewJobAt(t time.Time, job func() error) PendingJob {
+	s := jm.add("")
+	if t.After(s.queued) {
+		s.queued = t
+	}
+	tctx := jm.tracer.Submit(context.Background(), "")
+	return func() {
+		defer jm.wg.Done()
+		jm.begin(s)
+		err := jm.execute(context.Background(), tctx, s, withoutCtx(job), jm.timeout, nil)
+		jm.end(s, err)
+		jm.em.SetIfNotNil(err)
+	}
+}
+
+// NewJobChain create a PendingJob that execute the job again, including its retries, while next returns true. next
+// receives the final error of the execution, such as ErrJobPanic or ErrJobTimeout, or nil if it is succeeded. The whole
+// chain is counted as a single job by Wait, while every execution is counted by Stats.
+func (jm *JobManager) NewJobChain(job func() error, next func(err error) bool) PendingJob {
+	s := jm.add("")
+	tctx := jm.tracer.Submit(context.Background(), "")
 	return func() {
 		defer jm.wg.Done()
-		ats := 1 + jm.njr // 1 (base attempt) + num retry(s)
-		for at := 0; at < ats; at++ {
-			err := job()
-			if err == nil {
+		for {
+			jm.begin(s)
+			err := jm.execute(context.Background(), tctx, s, withoutCtx(job), jm.timeout, nil)
+			jm.end(s, err)
+			jm.em.SetIfNotNil(err)
+			if !next(err) {
 				return
 			}
-			if at != ats-1 {
-				continue
+			s = jm.enqueue("")
+		}
+	}
+}
+
+// NewJobHandle create a PendingJob that execute the job with the context of the returned JobHandle. The job is
+// skipped if the JobHandle is cancelled before it is executed, and it is not retried once the JobHandle is cancelled.
+// The error of a cancelled job is not reported to the ErrorManager. A non-positive o.Timeout means the default
+// timeout of the JobManager, and the values of o.Context are passed to the Tracer and the job.
+func (jm *JobManager) NewJobHandle(job func(ctx context.Context) error, o *OptionJob) (PendingJob, JobHandle) {
+	timeout := o.Timeout
+	if timeout <= 0 {
+		timeout = jm.timeout
+	}
+	ctx := o.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	jh := newJobHandle()
+	s := jm.add(o.Label)
+	jh.label = o.Label
+	tctx := jm.tracer.Submit(ctx, o.Label)
+	return func() {
+		defer jm.wg.Done()
+		var skipped bool
+		err := jm.execute(jh.ctx, tctx, s, job, timeout, func(attempt int) bool {
+			if !jh.start(attempt) {
+				skipped = true
+				return false
+			}
+			if attempt == 0 {
+				jm.begin(s)
 			}
+			return true
+		})
+		switch {
+		case skipped && s.begin.IsZero():
+			// Cancelled before it is executed
+			jm.drop(s, err)
+			return
+		case skipped:
+			// Cancelled between its attempts, the JobHandle is already completed
+			jm.end(s, err)
+			return
+		}
+		jm.end(s, err)
+		switch {
+		case err == nil:
+			jh.finish(JobSucceeded, nil)
+		case jh.ctx.Err() != nil:
+			jh.finish(JobCancelled, err)
+		default:
 			jm.em.SetIfNotNil(err)
+			jh.finish(JobFailed, err)
+		}
+	}, jh
+}
+
+// execute run the job with its retries and return the error of the last attempt. A failed attempt is retried unless
+// ctx is done or the error is not accepted by retryIf. When start is not nil, it is called before every attempt and
+// the job is not executed anymore once it returns false, then the error of ctx is returned.
+//
+// The Tracer is started with tctx, the context returned by Tracer.Submit, unless the job is skipped before its
+// first attempt. The job runs with the values of the context returned by Tracer.AttemptStart, while it is cancelled
+// with ctx.
+func (jm *JobManager) execute(ctx context.Context, tctx context.Context, s *jobState, job func(ctx context.Context) error, timeout time.Duration, start func(attempt int) bool) (err error) {
+	ats := 1 + jm.njr // 1 (base attempt) + num retry(s)
+	for at := 0; at < ats; at++ {
+		if start != nil && !start(at) {
+			return ctx.Err()
+		}
+		if at == 0 {
+			tctx = jm.tracer.Start(tctx)
+			defer func() {
+				jm.tracer.Finish(tctx, err)
+			}()
+		} else {
+			atomic.AddInt64(&jm.c.retried, 1)
+			s.attempt = at
+			Log(jm.logger, slog.LevelWarn, "job retrying", s.attrs(slog.Any("error", err))...)
+			call(jm.hooks.OnRetry, s, err)
+			jm.tracer.Retry(tctx, at, err)
+		}
+		actx := jm.tracer.AttemptStart(tctx, at)
+		start := time.Now()
+		err = jm.attempt(withValues(ctx, actx), job, timeout)
+		jm.c.attempt.record(time.Since(start))
+		if errors.Is(err, ErrJobPanic) {
+			Log(jm.logger, slog.LevelError, "job panicked", s.attrs(slog.Any("error", err))...)
+		}
+		jm.tracer.AttemptEnd(actx, at, err)
+		if err == nil || ctx.Err() != nil || (jm.retryIf != nil && !jm.retryIf(err)) {
+			return err
+		}
+	}
+	return err
+}
+
+// attempt run the job once. With a positive timeout, the job runs with a context deadline and attempt returns
+// ErrJobTimeout if the job returns an error after the deadline, while a job that returns nil is succeeded. The deadline
+// only cancels the context of the job, attempt always waits for the job to return, so a job that ignores its context
+// keeps its worker until it returns.
+func (jm *JobManager) attempt(ctx context.Context, job func(ctx context.Context) error, timeout time.Duration) error {
+	if timeout <= 0 {
+		return jm.run(ctx, job)
+	}
+	actx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := jm.run(actx, job)
+	if err != nil && ctx.Err() == nil && errors.Is(actx.Err(), context.DeadlineExceeded) {
+		return ErrJobTimeout
+	}
+	return err
+}
+
+// run call the job, and recover its panic as an error wrapping ErrJobPanic.
+func (jm *JobManager) run(ctx context.Context, job func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			atomic.AddInt64(&jm.c.panicked, 1)
+			err = fmt.Errorf("%w: %v", ErrJobPanic, r)
 		}
+	}()
+	return job(ctx)
+}
+
+func withoutCtx(job func() error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return job()
 	}
 }
 
@@ -35,14 +209,132 @@ func (jm *JobManager) NewJobSimple(job func()) PendingJob {
 	})
 }
 
+// Done mark a job created by NewJob or NewJobSimple that will never be executed as completed. err is the reason
+// the job is dropped.
+func (jm *JobManager) Done(err error) {
+	jm.drop(&jobState{}, err)
+	jm.wg.Done()
+}
+
+// Drop mark a job created by NewJobHandle that will never be executed as completed, and cancel its JobHandle
+// with err.
+func (jm *JobManager) Drop(handle JobHandle, err error) {
+	s := &jobState{}
+	if jh, ok := handle.(*jobHandle); ok {
+		jh.finish(JobCancelled, err)
+		s.label = jh.label
+	}
+	jm.drop(s, err)
+	jm.wg.Done()
+}
+
 func (jm *JobManager) Wait() {
 	jm.wg.Wait()
 }
 
-func NewJobManager(numJobRetry int, errorManager *ErrorManager) *JobManager {
+// NewGroup create a JobManager with its own Wait and ErrorManager that shares the options, the Stats, the recent
+// errors, the Tracer and the Hooks of jm, so the jobs of a group are counted once by jm with their real outcome.
+func (jm *JobManager) NewGroup(errorManager *ErrorManager) *JobManager {
+	group := *jm
+	group.wg = &sync.WaitGroup{}
+	group.em = errorManager
+	return &group
+}
+
+// Track create a PendingJob that execute pendingJob created by another JobManager, such as a group of jm. The job
+// is only counted by Wait, since it is already counted by the Stats of the JobManager that created it.
+func (jm *JobManager) Track(pendingJob PendingJob) PendingJob {
+	jm.wg.Add(1)
+	return func() {
+		defer jm.wg.Done()
+		pendingJob()
+	}
+}
+
+// Untrack mark a job created by Track that will never be executed as completed.
+func (jm *JobManager) Untrack() {
+	jm.wg.Done()
+}
+
+// Stats return a snapshot of the statistics of the jobs. IdleWorkers is not tracked by the JobManager.
+func (jm *JobManager) Stats() Stats {
+	return jm.c.snapshot()
+}
+
+// RecentErrors return the latest failed jobs, the latest first. At most the last 10 failed jobs are kept.
+func (jm *JobManager) RecentErrors() []JobError {
+	return jm.recent.snapshot()
+}
+
+// add count a new job for Wait and Stats, and return its jobState.
+func (jm *JobManager) add(label string) *jobState {
+	jm.wg.Add(1)
+	return jm.enqueue(label)
+}
+
+// enqueue count a job that is waiting to be executed, and return its jobState.
+func (jm *JobManager) enqueue(label string) *jobState {
+	atomic.AddInt64(&jm.c.submitted, 1)
+	atomic.AddInt64(&jm.c.queued, 1)
+	now := time.Now()
+	s := &jobState{label: label, submitted: now, queued: now}
+	call(jm.hooks.OnSubmit, s, nil)
+	return s
+}
+
+// begin count a queued job as running.
+func (jm *JobManager) begin(s *jobState) {
+	atomic.AddInt64(&jm.c.queued, -1)
+	atomic.AddInt64(&jm.c.running, 1)
+	s.begin = time.Now()
+	jm.c.queueWait.record(s.begin.Sub(s.queued))
+	call(jm.hooks.OnStart, s, nil)
+}
+
+// end count a running job as completed with err.
+func (jm *JobManager) end(s *jobState, err error) {
+	elapsed := time.Since(s.begin)
+	atomic.AddInt64(&jm.c.busy, int64(elapsed))
+	jm.c.execution.record(elapsed)
+	if err == nil {
+		atomic.AddInt64(&jm.c.succeeded, 1)
+		call(jm.hooks.OnSuccess, s, nil)
+	} else {
+		atomic.AddInt64(&jm.c.failed, 1)
+		jm.recent.add(JobError{Label: s.label, Time: time.Now(), Err: err})
+		Log(jm.logger, slog.LevelError, "job failed", s.attrs(slog.Any("error", err))...)
+		call(jm.hooks.OnFailure, s, err)
+	}
+	atomic.AddInt64(&jm.c.running, -1)
+}
+
+// drop count a queued job that will never be executed because of err.
+func (jm *JobManager) drop(s *jobState, err error) {
+	atomic.AddInt64(&jm.c.queued, -1)
+	atomic.AddInt64(&jm.c.dropped, 1)
+	Log(jm.logger, slog.LevelWarn, "job dropped", s.attrs(slog.Any("error", err))...)
+	call(jm.hooks.OnDrop, s, err)
+}
+
+// NewJobManager create a new JobManager with OptionJobManager. A positive JobTimeout limits every attempt of a job,
+// a non-nil RetryIf decides whether a failed attempt is retried, a non-nil Tracer is called around the execution of
+// every job, the Hooks are called on the lifecycle events of every job, and a non-nil Logger emits a record for every
+// retry, failure, panic and drop of a job.
+func NewJobManager(o OptionJobManager, errorManager *ErrorManager) *JobManager {
+	tracer := o.Tracer
+	if tracer == nil {
+		tracer = noopTracer{}
+	}
 	return &JobManager{
-		wg:  &sync.WaitGroup{},
-		njr: numJobRetry,
-		em:  errorManager,
+		wg:      &sync.WaitGroup{},
+		c:       &counters{},
+		recent:  newRecentErrors(),
+		njr:     o.Retry,
+		timeout: o.JobTimeout,
+		retryIf: o.RetryIf,
+		tracer:  tracer,
+		hooks:   o.Hooks,
+		logger:  o.Logger,
+		em:      errorManager,
 	}
 }
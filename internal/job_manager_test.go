@@ -1,15 +1,19 @@
 package internal
 
 import (
+	"context"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestJobManager(t *testing.T) {
 	type args struct {
 		numJobRetry int
+		jobTimeout  time.Duration
+		retryIf     func(err error) bool
 		e           *error
 		es          *[]error
 	}
@@ -123,10 +127,145 @@ func TestJobManager(t *testing.T) {
 			wantErr:     true,
 			wantErrsLen: 2,
 		},
+		{
+			name: "test chain with retry",
+			args: args{
+				numJobRetry: 1,
+				e: func() *error {
+					var err error
+					return &err
+				}(),
+				es: func() *[]error {
+					var errs []error
+					return &errs
+				}(),
+			},
+			runner: func(jm *JobManager) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				numNext := 2
+				j1 := jm.NewJobChain(func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return errors.New("an error")
+				}, func(err error) bool {
+					assert.EqualError(t, err, "an error")
+					numNext--
+					return numNext >= 0
+				})
+				go j1()
+				return &ret
+			},
+			wantRet:     (1 + 1) * 3, // (base attempt + num retry)*(base execution + num next)
+			wantErr:     true,
+			wantErrsLen: 3,
+		},
+		{
+			name: "test with job timeout",
+			args: args{
+				numJobRetry: 1,
+				jobTimeout:  time.Millisecond * 100,
+				e: func() *error {
+					var err error
+					return &err
+				}(),
+				es: func() *[]error {
+					var errs []error
+					return &errs
+				}(),
+			},
+			runner: func(jm *JobManager) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				start := time.Now()
+				// The job does not see the deadline, it is waited for and succeeds once it returns nil
+				j1 := jm.NewJob(func() error {
+					mu.Lock()
+					ret++
+					mu.Unlock()
+					time.Sleep(time.Millisecond * 150)
+					return nil
+				})
+				j1()
+				// The total block time should be around ~150 ms: the job is not retried
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Millisecond*150, ts)
+				assert.LessOrEqual(t, ts, (time.Millisecond*150)+(time.Millisecond*100)) // Add 0.1s as a threshold
+				// The job sees the deadline
+				j2, jh := jm.NewJobHandle(func(ctx context.Context) error {
+					mu.Lock()
+					ret++
+					mu.Unlock()
+					<-ctx.Done()
+					return ctx.Err()
+				}, &OptionJob{Timeout: time.Millisecond * 50})
+				j2()
+				assert.Equal(t, JobFailed, jh.Status())
+				assert.ErrorIs(t, jh.Err(), ErrJobTimeout)
+				// The job ignores the deadline and returns nil, its result is kept
+				j3, jh := jm.NewJobHandle(func(ctx context.Context) error {
+					mu.Lock()
+					ret++
+					mu.Unlock()
+					time.Sleep(time.Millisecond * 100)
+					return nil
+				}, &OptionJob{Timeout: time.Millisecond * 50})
+				j3()
+				assert.Equal(t, JobSucceeded, jh.Status())
+				assert.NoError(t, jh.Err())
+				return &ret
+			},
+			wantRet:     1 + (1 + 1) + 1, // not timed out + (base attempt + num retry) + not timed out
+			wantErr:     true,
+			wantErrsLen: 1,
+		},
+		{
+			name: "test with retry if",
+			args: args{
+				numJobRetry: 3,
+				retryIf: func(err error) bool {
+					return errors.Is(err, ErrJobTimeout)
+				},
+				e: func() *error {
+					var err error
+					return &err
+				}(),
+				es: func() *[]error {
+					var errs []error
+					return &errs
+				}(),
+			},
+			runner: func(jm *JobManager) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				j1 := jm.NewJob(func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return errors.New("an error")
+				})
+				go j1()
+				j2 := jm.NewJob(func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return ErrJobTimeout
+				})
+				go j2()
+				return &ret
+			},
+			wantRet:     1 + (1 + 3), // not retried + (base attempt + num retry)
+			wantErr:     true,
+			wantErrsLen: 2,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			jm := NewJobManager(tt.args.numJobRetry, NewErrorManager(tt.args.e, tt.args.es))
+			jm := NewJobManager(OptionJobManager{Retry: tt.args.numJobRetry, JobTimeout: tt.args.jobTimeout, RetryIf: tt.args.retryIf}, NewErrorManager(tt.args.e, tt.args.es))
 			if tt.runner != nil {
 				gotNumExecuted := tt.runner(jm)
 				jm.Wait()
@@ -149,3 +288,103 @@ func TestJobManager(t *testing.T) {
 		})
 	}
 }
+
+func TestJobManagerStats(t *testing.T) {
+	tests := []struct {
+		name      string
+		runner    func(jm *JobManager)
+		wantStats Stats
+	}{
+		{
+			name:      "test without job",
+			runner:    func(jm *JobManager) {},
+			wantStats: Stats{},
+		},
+		{
+			name: "test with succeeded, failed and retried jobs",
+			runner: func(jm *JobManager) {
+				jm.NewJobSimple(func() {})()
+				jm.NewJob(func() error { return errors.New("an error") })()
+			},
+			wantStats: Stats{Submitted: 2, Succeeded: 1, Failed: 1, Retried: 2},
+		},
+		{
+			name: "test with panicked job",
+			runner: func(jm *JobManager) {
+				jm.NewJobSimple(func() {
+					panic("a panic")
+				})()
+				pendingJob, jh := jm.NewJobHandle(func(ctx context.Context) error {
+					panic("a panic")
+				}, &OptionJob{Timeout: time.Millisecond * 100})
+				pendingJob()
+				assert.ErrorIs(t, jh.Err(), ErrJobPanic)
+			},
+			wantStats: Stats{Submitted: 2, Failed: 2, Retried: 4, Panicked: 6},
+		},
+		{
+			name: "test with dropped jobs",
+			runner: func(jm *JobManager) {
+				jm.NewJobSimple(func() {})
+				jm.Done(nil)
+				_, jh := jm.NewJobHandle(func(ctx context.Context) error { return nil }, &OptionJob{})
+				jm.Drop(jh, nil)
+				pendingJob, jh := jm.NewJobHandle(func(ctx context.Context) error { return nil }, &OptionJob{})
+				jh.Cancel()
+				pendingJob()
+			},
+			wantStats: Stats{Submitted: 3, Dropped: 3},
+		},
+		{
+			name: "test with chained job",
+			runner: func(jm *JobManager) {
+				numNext := 2
+				jm.NewJobChain(func() error { return nil }, func(err error) bool {
+					assert.NoError(t, err)
+					numNext--
+					return numNext >= 0
+				})()
+			},
+			wantStats: Stats{Submitted: 3, Succeeded: 3},
+		},
+		{
+			name: "test with queued and running jobs",
+			runner: func(jm *JobManager) {
+				release := make(chan struct{})
+				started := make(chan struct{})
+				go jm.NewJobSimple(func() {
+					close(started)
+					<-release
+				})()
+				queued := jm.NewJobSimple(func() {})
+				<-started
+				stats := jm.Stats()
+				assert.Equal(t, int64(1), stats.Queued)
+				assert.Equal(t, int64(1), stats.Running)
+				time.Sleep(time.Millisecond * 100)
+				close(release)
+				queued()
+				jm.Wait()
+				assert.LessOrEqual(t, time.Millisecond*100, jm.Stats().BusyTime)
+			},
+			wantStats: Stats{Submitted: 2, Succeeded: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jm := NewJobManager(OptionJobManager{Retry: 2}, NewErrorManager(nil, nil))
+			tt.runner(jm)
+			jm.Wait()
+			stats := jm.Stats()
+			// Every started job is recorded once, and every attempt is recorded once
+			started := stats.Succeeded + stats.Failed
+			assert.Equal(t, started, stats.QueueWait.Count)
+			assert.Equal(t, started, stats.ExecutionTime.Count)
+			assert.Equal(t, started+stats.Retried, stats.AttemptTime.Count)
+			assert.Equal(t, stats.BusyTime, stats.ExecutionTime.Sum)
+			stats.BusyTime = 0
+			stats.QueueWait, stats.AttemptTime, stats.ExecutionTime = Histogram{}, Histogram{}, Histogram{}
+			assert.Equal(t, tt.wantStats, stats)
+		})
+	}
+}
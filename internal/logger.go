@@ -0,0 +1,19 @@
+package internal
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Log emit a record with logger, or perform no-op if logger is nil so a worker is silent by default.
+func Log(logger *slog.Logger, level slog.Level, msg string, args ...any) {
+	if logger == nil {
+		return
+	}
+	logger.Log(context.Background(), level, msg, args...)
+}
+
+// attrs return the attributes of the job followed by args.
+func (s *jobState) attrs(args ...any) []any {
+	return append([]any{slog.String("label", s.label), slog.Int("attempt", s.attempt)}, args...)
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func newTestLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+func TestLogger(t *testing.T) {
+	tests := []struct {
+		name        string
+		runner      func(jm *JobManager)
+		wantRecords []string
+	}{
+		{
+			name: "test succeeded job",
+			runner: func(jm *JobManager) {
+				jm.NewJobSimple(func() {})()
+			},
+			wantRecords: nil,
+		},
+		{
+			name: "test job with retry",
+			runner: func(jm *JobManager) {
+				var attempts int
+				jm.NewJob(func() error {
+					if attempts++; attempts == 1 {
+						return errors.New("an error")
+					}
+					return nil
+				})()
+			},
+			wantRecords: []string{
+				`level=WARN msg="job retrying" label="" attempt=1 error="an error"`,
+			},
+		},
+		{
+			name: "test failed job with panic",
+			runner: func(jm *JobManager) {
+				pendingJob, _ := jm.NewJobHandle(func(ctx context.Context) error {
+					panic("a panic")
+				}, &OptionJob{Label: "a"})
+				pendingJob()
+			},
+			wantRecords: []string{
+				`level=ERROR msg="job panicked" label=a attempt=0 error="job panic: a panic"`,
+				`level=WARN msg="job retrying" label=a attempt=1 error="job panic: a panic"`,
+				`level=ERROR msg="job panicked" label=a attempt=1 error="job panic: a panic"`,
+				`level=ERROR msg="job failed" label=a attempt=1 error="job panic: a panic"`,
+			},
+		},
+		{
+			name: "test dropped job",
+			runner: func(jm *JobManager) {
+				jm.NewJobSimple(func() {})
+				jm.Done(errors.New("closed"))
+			},
+			wantRecords: []string{
+				`level=WARN msg="job dropped" label="" attempt=0 error=closed`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			jm := NewJobManager(OptionJobManager{Retry: 1, Logger: newTestLogger(buf)}, NewErrorManager(nil, nil))
+			tt.runner(jm)
+			jm.Wait()
+			var gotRecords []string
+			if buf.Len() > 0 {
+				gotRecords = strings.Split(strings.TrimSpace(buf.String()), "\n")
+			}
+			assert.Equal(t, tt.wantRecords, gotRecords)
+		})
+	}
+}
+
+func TestLoggerNil(t *testing.T) {
+	jm := NewJobManager(OptionJobManager{}, NewErrorManager(nil, nil))
+	jm.NewJob(func() error { return errors.New("an error") })()
+	Log(nil, slog.LevelError, "an error")
+}
@@ -1,17 +1,54 @@
 package internal
 
-import "time"
+import (
+	"context"
+	"log/slog"
+	"time"
+)
 
 type OptionPool struct {
+	Name           string
 	JobPoolSize    int
 	StartupStagger time.Duration
-	Retry          int
+	PriorityAging  time.Duration
+	Classes        map[string]int
+	WeightCapacity int64
+	Watchdog       time.Duration
+	OnStuck        func(job StuckJob)
+	Profiling      bool
+	Expvar         string
 	Err            *error
 	Errs           *[]error
+	OptionJobManager
 }
 
 type OptionFlex struct {
-	Retry int
-	Err   *error
-	Errs  *[]error
+	Profiling bool
+	Err       *error
+	Errs      *[]error
+	OptionJobManager
+}
+
+type OptionJobManager struct {
+	JobTimeout time.Duration
+	Tracer     Tracer
+	Hooks      Hooks
+	Logger     *slog.Logger
+	Retry      int
+	RetryIf    func(err error) bool
+}
+
+type OptionJob struct {
+	Timeout time.Duration
+	Label   string
+	Context context.Context
+}
+
+type OptionEntry struct {
+	Overlap int
+	Jitter  time.Duration
+}
+
+type OptionMetrics struct {
+	Namespace string
 }
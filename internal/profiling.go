@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"context"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// ProfileTaskName is the name of the runtime/trace task and region of a job executed with Profile.
+const ProfileTaskName = "bworker.job"
+
+// ProfileLabels return ctx with the pprof labels of args as key-value pairs. A label with an empty value is omitted.
+func ProfileLabels(ctx context.Context, args ...string) context.Context {
+	var labels []string
+	for i := 0; i+1 < len(args); i += 2 {
+		if args[i+1] != "" {
+			labels = append(labels, args[i], args[i+1])
+		}
+	}
+	return pprof.WithLabels(ctx, pprof.Labels(labels...))
+}
+
+// Profile execute the job with the pprof labels of ctx and the bworker.job label, so CPU profiles attribute its cost
+// to them, and within a runtime/trace task and region named ProfileTaskName. The labels of ctx are restored once the
+// job is completed.
+func Profile(ctx context.Context, label string, job func()) {
+	labels := pprof.Labels()
+	if label != "" {
+		labels = pprof.Labels("bworker.job", label)
+	}
+	pprof.Do(ctx, labels, func(ctx context.Context) {
+		ctx, task := trace.NewTask(ctx, ProfileTaskName)
+		defer task.End()
+		pprof.ForLabels(ctx, func(key, value string) bool {
+			trace.Log(ctx, key, value)
+			return true
+		})
+		trace.WithRegion(ctx, ProfileTaskName, job)
+	})
+}
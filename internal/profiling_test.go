@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"runtime/pprof"
+	"runtime/trace"
+	"testing"
+)
+
+func TestProfileLabels(t *testing.T) {
+	ctx := ProfileLabels(context.Background(), "bworker.pool", "a", "bworker.worker", "", "odd")
+	var got []string
+	pprof.ForLabels(ctx, func(key, value string) bool {
+		got = append(got, key+"="+value)
+		return true
+	})
+	assert.Equal(t, []string{"bworker.pool=a"}, got)
+}
+
+func TestProfile(t *testing.T) {
+	tests := []struct {
+		name       string
+		label      string
+		wantLabels string
+	}{
+		{
+			name:       "test job with label",
+			label:      "a",
+			wantLabels: `# labels: {"bworker.job":"a", "bworker.pool":"p"}`,
+		},
+		{
+			name:       "test job without label",
+			label:      "",
+			wantLabels: `# labels: {"bworker.pool":"p"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := ProfileLabels(context.Background(), "bworker.pool", "p")
+			traced := &bytes.Buffer{}
+			assert.NoError(t, trace.Start(traced))
+			var executed bool
+			Profile(ctx, tt.label, func() {
+				executed = true
+				goroutines := &bytes.Buffer{}
+				assert.NoError(t, pprof.Lookup("goroutine").WriteTo(goroutines, 1))
+				assert.Contains(t, goroutines.String(), tt.wantLabels)
+			})
+			trace.Stop()
+			assert.True(t, executed)
+			assert.Contains(t, traced.String(), ProfileTaskName)
+		})
+	}
+}
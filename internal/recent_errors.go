@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"sync"
+	"time"
+)
+
+// recentErrorsSize is the number of the latest failed jobs kept by a JobManager.
+const recentErrorsSize = 10
+
+// JobError describe a failed job kept by the JobManager as one of its recent errors.
+type JobError struct {
+	// Label is the label of the job, or empty if the job is submitted without a label.
+	Label string
+	// Time is the time the job failed.
+	Time time.Time
+	// Err is the error of the last attempt of the job.
+	Err error
+}
+
+// recentErrors is a ring of the latest JobError(s).
+type recentErrors struct {
+	mu   *sync.Mutex
+	errs []JobError
+	next int
+}
+
+func (re *recentErrors) add(je JobError) {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	if len(re.errs) < recentErrorsSize {
+		re.errs = append(re.errs, je)
+		return
+	}
+	re.errs[re.next] = je
+	re.next = (re.next + 1) % recentErrorsSize
+}
+
+// snapshot return a copy of the JobError(s), the latest first.
+func (re *recentErrors) snapshot() []JobError {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	errs := make([]JobError, 0, len(re.errs))
+	for i := len(re.errs) - 1; i >= 0; i-- {
+		errs = append(errs, re.errs[(re.next+i)%len(re.errs)])
+	}
+	return errs
+}
+
+func newRecentErrors() *recentErrors {
+	return &recentErrors{mu: &sync.Mutex{}}
+}
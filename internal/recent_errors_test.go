@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRecentErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		numErrors  int
+		wantLabels []string
+	}{
+		{
+			name:       "test no error",
+			numErrors:  0,
+			wantLabels: []string{},
+		},
+		{
+			name:       "test less than the size",
+			numErrors:  3,
+			wantLabels: []string{"2", "1", "0"},
+		},
+		{
+			name:       "test more than the size",
+			numErrors:  recentErrorsSize + 2,
+			wantLabels: []string{"11", "10", "9", "8", "7", "6", "5", "4", "3", "2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jm := NewJobManager(OptionJobManager{}, nil)
+			for i := 0; i < tt.numErrors; i++ {
+				pendingJob, _ := jm.NewJobHandle(func(ctx context.Context) error {
+					return errors.New("an error")
+				}, &OptionJob{Label: fmt.Sprint(i)})
+				pendingJob()
+			}
+			gotLabels := []string{}
+			for _, je := range jm.RecentErrors() {
+				assert.EqualError(t, je.Err, "an error")
+				assert.False(t, je.Time.IsZero())
+				gotLabels = append(gotLabels, je.Label)
+			}
+			assert.Equal(t, tt.wantLabels, gotLabels)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// PoolInfo is a snapshot of a live worker pool listed by the registry.
+type PoolInfo struct {
+	// ID identifies the pool in the registry until it is shut down. A restarted pool gets a new ID.
+	ID uint64
+	// Name is the name of the pool set with WithName, or empty if it is not set.
+	Name string
+	// State is the lifecycle state of the pool, such as running or paused.
+	State string
+	// Concurrency is the number of workers of the pool.
+	Concurrency int
+	// Stats is the runtime statistics of the pool.
+	Stats Stats
+	// Running is the jobs being executed by the workers of the pool, ordered by worker.
+	Running []RunningJob
+	// Errors is the latest failed jobs of the pool, the latest first.
+	Errors []JobError
+}
+
+// RunningJob describe a job being executed by a worker of a pool.
+type RunningJob struct {
+	// Label is the label of the job, or empty if the job is submitted without a label.
+	Label string
+	// Worker is the index of the worker executing the job, starting from 0.
+	Worker int
+	// Elapsed is the time since the worker started the job.
+	Elapsed time.Duration
+}
+
+// Registered is a live worker pool listed by the registry, that can be controlled by its ID.
+type Registered interface {
+	Info() PoolInfo
+	Pause()
+	Resume()
+	Resize(concurrency int)
+}
+
+var registry = struct {
+	mu    *sync.Mutex
+	seq   uint64
+	pools map[Registered]uint64
+}{
+	mu:    &sync.Mutex{},
+	pools: make(map[Registered]uint64),
+}
+
+// Register add a live worker pool to the registry. If the pool is already registered this function will perform
+// no-op.
+func Register(r Registered) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, ok := registry.pools[r]; ok {
+		return
+	}
+	registry.seq++
+	registry.pools[r] = registry.seq
+}
+
+// Unregister remove a worker pool from the registry. If the pool is not registered this function will perform no-op.
+func Unregister(r Registered) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	delete(registry.pools, r)
+}
+
+// Lookup return the registered worker pool with the ID of its PoolInfo, or false if it is not registered anymore.
+func Lookup(id uint64) (Registered, bool) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for r, seq := range registry.pools {
+		if seq == id {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// Pools return the PoolInfo of every registered worker pool, sorted by name and then by registration order.
+func Pools() []PoolInfo {
+	type entry struct {
+		seq  uint64
+		info PoolInfo
+	}
+	registry.mu.Lock()
+	entries := make([]entry, 0, len(registry.pools))
+	pools := make([]Registered, 0, len(registry.pools))
+	for r, seq := range registry.pools {
+		entries = append(entries, entry{seq: seq})
+		pools = append(pools, r)
+	}
+	registry.mu.Unlock()
+	// Take the snapshots outside of the lock, since a pool may be registering or unregistering itself meanwhile
+	for i, r := range pools {
+		entries[i].info = r.Info()
+		entries[i].info.ID = entries[i].seq
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].info.Name != entries[j].info.Name {
+			return entries[i].info.Name < entries[j].info.Name
+		}
+		return entries[i].seq < entries[j].seq
+	})
+	infos := make([]PoolInfo, len(entries))
+	for i, e := range entries {
+		infos[i] = e.info
+	}
+	return infos
+}
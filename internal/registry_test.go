@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type fakeRegistered struct {
+	name, state string
+	concurrency int
+}
+
+func (f *fakeRegistered) Info() PoolInfo {
+	return PoolInfo{Name: f.name, State: f.state, Concurrency: f.concurrency}
+}
+
+func (f *fakeRegistered) Pause() {
+	f.state = "paused"
+}
+
+func (f *fakeRegistered) Resume() {
+	f.state = "running"
+}
+
+func (f *fakeRegistered) Resize(concurrency int) {
+	f.concurrency = concurrency
+}
+
+func TestRegistry(t *testing.T) {
+	names := func() []string {
+		var names []string
+		for _, info := range Pools() {
+			names = append(names, info.Name+"/"+info.State)
+		}
+		return names
+	}
+	a, b1, b2 := &fakeRegistered{name: "a", state: "1"}, &fakeRegistered{name: "b", state: "1"}, &fakeRegistered{name: "b", state: "2"}
+	Register(b2)
+	Register(a)
+	Register(b1)
+	// Registering twice keeps the first registration order
+	Register(b2)
+	assert.Equal(t, []string{"a/1", "b/2", "b/1"}, names())
+
+	id := Pools()[1].ID
+	r, ok := Lookup(id)
+	assert.True(t, ok)
+	assert.Equal(t, b2, r)
+	r.Resize(3)
+	assert.Equal(t, 3, Pools()[1].Concurrency)
+
+	Unregister(b2)
+	Unregister(b2)
+	_, ok = Lookup(id)
+	assert.False(t, ok)
+	assert.Equal(t, []string{"a/1", "b/1"}, names())
+	Unregister(a)
+	Unregister(b1)
+	assert.Empty(t, Pools())
+}
@@ -0,0 +1,319 @@
+package internal
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultClass is the class of jobs submitted without a class or with an unknown class.
+const DefaultClass = ""
+
+// Scheduler is a bounded queue of PendingJob ordered by priority. Jobs with a higher priority are popped first and
+// jobs with the same priority are popped in submission order.
+//
+// When aging is set, a queued job gains 1 priority for every aging duration it has been waiting, so low priority
+// jobs are not starved by a steady stream of high priority jobs.
+//
+// Jobs are grouped into weighted classes, each with its own priority queue. Classes are served with weighted fair
+// queuing: while several classes have queued jobs, each of them is popped in proportion to its weight, and a class
+// without queued jobs leaves its share to the others.
+//
+// When a weight capacity is set, every job holds its weight from the moment it is popped until it is completed, and
+// a job is only popped when its weight fits into the remaining capacity. Like a semaphore, the job to be served next
+// waits for enough capacity instead of being overtaken by lighter jobs, so heavy jobs are not starved.
+type Scheduler struct {
+	mu       *sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	size     int
+	// weightCapacity is the total weight of jobs that can be executed at the same time, 0 means unlimited.
+	weightCapacity int64
+	weightInUse    int64
+	aging          time.Duration
+	epoch          time.Time
+	seq            uint64
+	classes        map[string]*schedulerClass
+	// order holds the classes sorted by name, so ties between classes are always broken the same way.
+	order []*schedulerClass
+	// vtime is the virtual time of the scheduler, which is the pass of the latest served class.
+	vtime  float64
+	len    int
+	paused bool
+	closed bool
+	// retire is the number of consumers to be stopped by Pop, see Retire.
+	retire int
+}
+
+type schedulerClass struct {
+	name   string
+	weight int
+	// pass is the virtual time at which the class will be served next. It advances by 1/weight every time the
+	// class is served, so heavier classes are served more often.
+	pass  float64
+	queue *scheduledJobs
+}
+
+type scheduledJob struct {
+	job   PendingJob
+	label string
+	// rank is the effective priority of the job relative to the scheduler epoch. Since every queued job ages at
+	// the same rate, comparing ranks gives the same order at any point in time.
+	rank   float64
+	seq    uint64
+	weight int64
+	index  int
+}
+
+type scheduledJobs []*scheduledJob
+
+func (sj scheduledJobs) Len() int { return len(sj) }
+
+func (sj scheduledJobs) Less(i, j int) bool {
+	if sj[i].rank != sj[j].rank {
+		return sj[i].rank > sj[j].rank
+	}
+	return sj[i].seq < sj[j].seq
+}
+
+func (sj scheduledJobs) Swap(i, j int) {
+	sj[i], sj[j] = sj[j], sj[i]
+	sj[i].index = i
+	sj[j].index = j
+}
+
+func (sj *scheduledJobs) Push(x interface{}) {
+	item := x.(*scheduledJob)
+	item.index = len(*sj)
+	*sj = append(*sj, item)
+}
+
+func (sj *scheduledJobs) Pop() interface{} {
+	old := *sj
+	n := len(old)
+	x := old[n-1]
+	x.index = -1
+	old[n-1] = nil
+	*sj = old[:n-1]
+	return x
+}
+
+// Push queue the job with the given class, priority and weight. It returns false if the scheduler is closed.
+// A non-positive weight is counted as 1 and a weight larger than the weight capacity is counted as the weight capacity.
+//
+// When the scheduler is full, the job is still ranked against the other queued jobs right away, but Push blocks
+// until the job is executed or the number of queued jobs drops to the size. This way a high priority job does
+// not wait behind low priority jobs whose submitters are blocked.
+func (s *Scheduler) Push(job PendingJob, class string, priority int, weight int64) bool {
+	return s.PushLabeled(job, "", class, priority, weight)
+}
+
+// PushLabeled queue the job like Push with a label that is returned along with the job by PopLabeled.
+func (s *Scheduler) PushLabeled(job PendingJob, label string, class string, priority int, weight int64) bool {
+	return s.push(job, label, class, priority, weight, true)
+}
+
+// PushNoWait queue the job like PushLabeled without waiting for the number of queued jobs to drop to the size, so the
+// scheduler may hold more jobs than its size.
+func (s *Scheduler) PushNoWait(job PendingJob, label string, class string, priority int, weight int64) bool {
+	return s.push(job, label, class, priority, weight, false)
+}
+
+func (s *Scheduler) push(job PendingJob, label string, class string, priority int, weight int64, wait bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	c, ok := s.classes[class]
+	if !ok {
+		c = s.classes[DefaultClass]
+	}
+	rank := float64(priority)
+	if s.aging > 0 {
+		rank -= float64(time.Since(s.epoch)) / float64(s.aging)
+	}
+	if c.queue.Len() == 0 && c.pass < s.vtime {
+		// An idle class does not accumulate credit while it has nothing to execute
+		c.pass = s.vtime
+	}
+	if weight <= 0 {
+		weight = 1
+	}
+	if s.weightCapacity > 0 && weight > s.weightCapacity {
+		weight = s.weightCapacity
+	}
+	s.seq++
+	sj := &scheduledJob{job: job, label: label, rank: rank, seq: s.seq, weight: weight}
+	heap.Push(c.queue, sj)
+	s.len++
+	s.notEmpty.Broadcast()
+	for wait && !s.closed && sj.index >= 0 && s.len > s.size {
+		s.notFull.Wait()
+	}
+	return true
+}
+
+// Pop remove and return the job with the highest priority of the class to be served next. It blocks while the
+// scheduler is empty, paused or the weight of the next job does not fit, and returns false if the scheduler is closed
+// and has no job left.
+func (s *Scheduler) Pop() (PendingJob, bool) {
+	job, _, ok := s.PopLabeled()
+	return job, ok
+}
+
+// PopLabeled remove and return the job like Pop along with its label.
+func (s *Scheduler) PopLabeled() (PendingJob, string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		for !s.closed && s.retire == 0 && (s.len == 0 || s.paused) {
+			s.notEmpty.Wait()
+		}
+		if s.retire > 0 {
+			s.retire--
+			return nil, "", false
+		}
+		if s.len == 0 {
+			return nil, "", false
+		}
+		var next *schedulerClass
+		for _, c := range s.order {
+			if c.queue.Len() != 0 && (next == nil || c.pass < next.pass) {
+				next = c
+			}
+		}
+		weight := (*next.queue)[0].weight
+		if s.weightCapacity > 0 && s.weightInUse+weight > s.weightCapacity {
+			// Wait for running jobs to release their weight, the next job may change in the meantime
+			s.notEmpty.Wait()
+			continue
+		}
+		s.vtime = next.pass
+		next.pass += 1 / float64(next.weight)
+		sj := heap.Pop(next.queue).(*scheduledJob)
+		s.len--
+		s.notFull.Broadcast()
+		if s.weightCapacity == 0 {
+			return sj.job, sj.label, true
+		}
+		s.weightInUse += weight
+		return func() {
+			defer s.release(weight)
+			sj.job()
+		}, sj.label, true
+	}
+}
+
+func (s *Scheduler) release(weight int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.weightInUse -= weight
+	s.notEmpty.Broadcast()
+}
+
+// Pause stop Pop from returning jobs until Resume is called or the scheduler is closed. Push keeps queueing jobs.
+func (s *Scheduler) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = true
+}
+
+// Resume let Pop return jobs again after Pause.
+func (s *Scheduler) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = false
+	s.notEmpty.Broadcast()
+}
+
+// Paused indicates the scheduler is paused or not.
+func (s *Scheduler) Paused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
+
+// Retire make the next n calls of Pop return false without a job, even if the scheduler is paused or has queued
+// jobs, so n consumers stop while the others keep executing the queued jobs.
+func (s *Scheduler) Retire(n int) {
+	if n <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retire += n
+	s.notEmpty.Broadcast()
+}
+
+// SetSize change the number of queued jobs before Push blocks. A non-positive size is counted as 1.
+func (s *Scheduler) SetSize(size int) {
+	if size <= 0 {
+		size = 1
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.size = size
+	s.notFull.Broadcast()
+}
+
+// Len return the number of queued jobs.
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.len
+}
+
+// Close stop accepting new jobs. Blocked Push will return and Pop will keep returning the queued jobs until
+// the scheduler is empty.
+func (s *Scheduler) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.notEmpty.Broadcast()
+	s.notFull.Broadcast()
+}
+
+// Reopen accept new jobs again after Close. It must be called once Pop returned false to every consumer.
+func (s *Scheduler) Reopen() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = false
+	s.retire = 0
+}
+
+// NewScheduler create a new Scheduler. The classes map a class name to its weight, the DefaultClass has a weight
+// of 1 unless it is specified. A non-positive weightCapacity means the weight of jobs is not limited.
+func NewScheduler(size int, aging time.Duration, classes map[string]int, weightCapacity int64) *Scheduler {
+	if size <= 0 {
+		size = 1
+	}
+	if weightCapacity < 0 {
+		weightCapacity = 0
+	}
+	mu := &sync.Mutex{}
+	s := &Scheduler{
+		mu:             mu,
+		notEmpty:       sync.NewCond(mu),
+		notFull:        sync.NewCond(mu),
+		size:           size,
+		weightCapacity: weightCapacity,
+		aging:          aging,
+		epoch:          time.Now(),
+		classes:        map[string]*schedulerClass{DefaultClass: {name: DefaultClass, weight: 1, queue: &scheduledJobs{}}},
+	}
+	for name, weight := range classes {
+		if weight <= 0 {
+			continue
+		}
+		s.classes[name] = &schedulerClass{name: name, weight: weight, queue: &scheduledJobs{}}
+	}
+	for _, c := range s.classes {
+		s.order = append(s.order, c)
+	}
+	sort.Slice(s.order, func(i, j int) bool {
+		return s.order[i].name < s.order[j].name
+	})
+	return s
+}
@@ -0,0 +1,363 @@
+package internal
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	type args struct {
+		size           int
+		aging          time.Duration
+		classes        map[string]int
+		weightCapacity int64
+	}
+	tests := []struct {
+		name   string
+		args   args
+		runner func(s *Scheduler)
+	}{
+		{
+			name: "test use default value",
+			args: args{
+				size:  -1,
+				aging: 0,
+			},
+			runner: func(s *Scheduler) {
+				assert.True(t, s.Push(func() {}, DefaultClass, 0, 1))
+				assert.Equal(t, 1, s.Len())
+			},
+		},
+		{
+			name: "test pop by priority then submission order",
+			args: args{
+				size:  10,
+				aging: 0,
+			},
+			runner: func(s *Scheduler) {
+				var got []int
+				for i, priority := range []int{0, 5, 0, 10, 5} {
+					icp := i
+					assert.True(t, s.Push(func() { got = append(got, icp) }, DefaultClass, priority, 1))
+				}
+				for i := 0; i < 5; i++ {
+					job, ok := s.Pop()
+					assert.True(t, ok)
+					job()
+				}
+				assert.Equal(t, []int{3, 1, 4, 0, 2}, got)
+			},
+		},
+		{
+			name: "test pop with aging",
+			args: args{
+				size:  10,
+				aging: time.Millisecond * 10,
+			},
+			runner: func(s *Scheduler) {
+				var got []int
+				assert.True(t, s.Push(func() { got = append(got, 0) }, DefaultClass, 0, 1))
+				time.Sleep(time.Millisecond * 50)
+				assert.True(t, s.Push(func() { got = append(got, 1) }, DefaultClass, 2, 1))
+				assert.True(t, s.Push(func() { got = append(got, 2) }, DefaultClass, 10, 1))
+				for i := 0; i < 3; i++ {
+					job, ok := s.Pop()
+					assert.True(t, ok)
+					job()
+				}
+				assert.Equal(t, []int{2, 0, 1}, got)
+			},
+		},
+		{
+			name: "test push blocked when full",
+			args: args{
+				size:  1,
+				aging: 0,
+			},
+			runner: func(s *Scheduler) {
+				assert.True(t, s.Push(func() {}, DefaultClass, 0, 1))
+				go func() {
+					time.Sleep(time.Second)
+					_, ok := s.Pop()
+					assert.True(t, ok)
+				}()
+				start := time.Now()
+				assert.True(t, s.Push(func() {}, DefaultClass, 0, 1))
+				// The total block time should be around ~ 1sec
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Second, ts)
+				assert.LessOrEqual(t, ts, time.Second+(time.Millisecond*100)) // Add 0.1s as a threshold
+			},
+		},
+		{
+			name: "test push without waiting when full",
+			args: args{
+				size:  1,
+				aging: 0,
+			},
+			runner: func(s *Scheduler) {
+				assert.True(t, s.Push(func() {}, DefaultClass, 0, 1))
+				start := time.Now()
+				assert.True(t, s.PushNoWait(func() {}, "a", DefaultClass, 0, 1))
+				assert.LessOrEqual(t, time.Since(start), time.Millisecond*100) // Add 0.1s as a threshold
+				assert.Equal(t, 2, s.Len())
+				s.Close()
+				assert.False(t, s.PushNoWait(func() {}, "a", DefaultClass, 0, 1))
+			},
+		},
+		{
+			name: "test push when full ranked before blocked jobs",
+			args: args{
+				size:  1,
+				aging: 0,
+			},
+			runner: func(s *Scheduler) {
+				var got []int
+				assert.True(t, s.Push(func() { got = append(got, 0) }, DefaultClass, 0, 1))
+				wg := &sync.WaitGroup{}
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					assert.True(t, s.Push(func() { got = append(got, 1) }, DefaultClass, 0, 1))
+				}()
+				time.Sleep(time.Millisecond * 100)
+				go func() {
+					defer wg.Done()
+					assert.True(t, s.Push(func() { got = append(got, 2) }, DefaultClass, 10, 1))
+				}()
+				time.Sleep(time.Millisecond * 100)
+				assert.Equal(t, 3, s.Len())
+				for i := 0; i < 3; i++ {
+					job, ok := s.Pop()
+					assert.True(t, ok)
+					job()
+				}
+				wg.Wait()
+				assert.Equal(t, []int{2, 0, 1}, got)
+			},
+		},
+		{
+			name: "test pop by class weight",
+			args: args{
+				size:    100,
+				aging:   0,
+				classes: map[string]int{"a": 3, "b": 1, "c": 0},
+			},
+			runner: func(s *Scheduler) {
+				got := make(map[string]int)
+				for i := 0; i < 20; i++ {
+					for _, class := range []string{"a", "b", "c"} {
+						ccp := class
+						assert.True(t, s.Push(func() { got[ccp]++ }, ccp, 0, 1))
+					}
+				}
+				// Class c is unknown since its weight is invalid, so it is served as DefaultClass with weight 1
+				for i := 0; i < 25; i++ {
+					job, ok := s.Pop()
+					assert.True(t, ok)
+					job()
+				}
+				assert.Equal(t, map[string]int{"a": 15, "b": 5, "c": 5}, got)
+				// Class a is drained, the remaining capacity is shared by the other classes
+				for i := 0; i < 35; i++ {
+					job, ok := s.Pop()
+					assert.True(t, ok)
+					job()
+				}
+				assert.Equal(t, map[string]int{"a": 20, "b": 20, "c": 20}, got)
+			},
+		},
+		{
+			name: "test idle class does not accumulate credit",
+			args: args{
+				size:    100,
+				aging:   0,
+				classes: map[string]int{"a": 1, "b": 1},
+			},
+			runner: func(s *Scheduler) {
+				var got []string
+				for i := 0; i < 10; i++ {
+					assert.True(t, s.Push(func() { got = append(got, "a") }, "a", 0, 1))
+				}
+				for i := 0; i < 6; i++ {
+					job, ok := s.Pop()
+					assert.True(t, ok)
+					job()
+				}
+				for i := 0; i < 2; i++ {
+					assert.True(t, s.Push(func() { got = append(got, "b") }, "b", 0, 1))
+				}
+				got = nil
+				for i := 0; i < 4; i++ {
+					job, ok := s.Pop()
+					assert.True(t, ok)
+					job()
+				}
+				assert.Equal(t, []string{"b", "a", "b", "a"}, got)
+			},
+		},
+		{
+			name: "test pop when weight fits",
+			args: args{
+				size:           10,
+				aging:          0,
+				weightCapacity: 10,
+			},
+			runner: func(s *Scheduler) {
+				assert.True(t, s.Push(func() {}, DefaultClass, 0, 8))
+				assert.True(t, s.Push(func() {}, DefaultClass, 0, 3))
+				assert.True(t, s.Push(func() {}, DefaultClass, 0, 3))
+				assert.True(t, s.Push(func() {}, DefaultClass, 0, 100))
+				heavy, ok := s.Pop()
+				assert.True(t, ok)
+				go func() {
+					time.Sleep(time.Second)
+					heavy()
+				}()
+				// Blocked until the heavy job releases its weight
+				start := time.Now()
+				light1, ok := s.Pop()
+				assert.True(t, ok)
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Second, ts)
+				assert.LessOrEqual(t, ts, time.Second+(time.Millisecond*100)) // Add 0.1s as a threshold
+				// Both light jobs fit at the same time
+				light2, ok := s.Pop()
+				assert.True(t, ok)
+				go func() {
+					time.Sleep(time.Second)
+					light1()
+					light2()
+				}()
+				// The oversized job is counted as the weight capacity
+				_, ok = s.Pop()
+				assert.True(t, ok)
+				ts = time.Since(start)
+				assert.LessOrEqual(t, time.Second*2, ts)
+				assert.LessOrEqual(t, ts, (time.Second*2)+(time.Millisecond*100)) // Add 0.1s as a threshold
+			},
+		},
+		{
+			name: "test pause and resume",
+			args: args{
+				size:  2,
+				aging: 0,
+			},
+			runner: func(s *Scheduler) {
+				s.Pause()
+				assert.True(t, s.Paused())
+				assert.True(t, s.Push(func() {}, DefaultClass, 0, 1))
+				assert.True(t, s.Push(func() {}, DefaultClass, 0, 1))
+				assert.Equal(t, 2, s.Len())
+				go func() {
+					time.Sleep(time.Millisecond * 100)
+					s.Resume()
+				}()
+				// Blocked until resumed
+				start := time.Now()
+				_, ok := s.Pop()
+				assert.True(t, ok)
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Millisecond*100, ts)
+				assert.LessOrEqual(t, ts, (time.Millisecond*100)+(time.Millisecond*100)) // Add 0.1s as a threshold
+				assert.False(t, s.Paused())
+				// Queued jobs are still returned after closed while paused
+				s.Pause()
+				s.Close()
+				_, ok = s.Pop()
+				assert.True(t, ok)
+				_, ok = s.Pop()
+				assert.False(t, ok)
+			},
+		},
+		{
+			name: "test retire",
+			args: args{
+				size:  2,
+				aging: 0,
+			},
+			runner: func(s *Scheduler) {
+				s.Retire(0)
+				s.Pause()
+				assert.True(t, s.Push(func() {}, DefaultClass, 0, 1))
+				// Retired even if paused with a queued job
+				s.Retire(2)
+				for i := 0; i < 2; i++ {
+					_, ok := s.Pop()
+					assert.False(t, ok)
+				}
+				go func() {
+					time.Sleep(time.Millisecond * 100)
+					s.Retire(1)
+				}()
+				// Blocked until retired
+				start := time.Now()
+				_, ok := s.Pop()
+				assert.False(t, ok)
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Millisecond*100, ts)
+				assert.LessOrEqual(t, ts, (time.Millisecond*100)+(time.Millisecond*100)) // Add 0.1s as a threshold
+				s.Resume()
+				_, ok = s.Pop()
+				assert.True(t, ok)
+			},
+		},
+		{
+			name: "test set size",
+			args: args{
+				size:  1,
+				aging: 0,
+			},
+			runner: func(s *Scheduler) {
+				s.SetSize(-1)
+				assert.True(t, s.Push(func() {}, DefaultClass, 0, 1))
+				go func() {
+					time.Sleep(time.Millisecond * 100)
+					s.SetSize(2)
+				}()
+				// Blocked until the size is increased
+				start := time.Now()
+				assert.True(t, s.Push(func() {}, DefaultClass, 0, 1))
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Millisecond*100, ts)
+				assert.LessOrEqual(t, ts, (time.Millisecond*100)+(time.Millisecond*100)) // Add 0.1s as a threshold
+				assert.Equal(t, 2, s.Len())
+			},
+		},
+		{
+			name: "test close",
+			args: args{
+				size:  1,
+				aging: 0,
+			},
+			runner: func(s *Scheduler) {
+				assert.True(t, s.Push(func() {}, DefaultClass, 0, 1))
+				go func() {
+					time.Sleep(time.Millisecond * 100)
+					s.Close()
+				}()
+				// Blocked until closed
+				assert.True(t, s.Push(func() {}, DefaultClass, 0, 1))
+				assert.False(t, s.Push(func() {}, DefaultClass, 0, 1))
+				// Queued jobs are still returned after closed
+				for i := 0; i < 2; i++ {
+					_, ok := s.Pop()
+					assert.True(t, ok)
+				}
+				_, ok := s.Pop()
+				assert.False(t, ok)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScheduler(tt.args.size, tt.args.aging, tt.args.classes, tt.args.weightCapacity)
+			defer s.Close()
+			if tt.runner != nil {
+				tt.runner(s)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the runtime statistics of a worker.
+type Stats struct {
+	// Queued is the number of submitted jobs waiting to be executed, including the delayed jobs.
+	Queued int64
+	// Running is the number of jobs being executed.
+	Running int64
+	// IdleWorkers is the number of workers waiting for a job.
+	IdleWorkers int64
+	// Submitted is the number of accepted jobs. Every execution of a chained job is counted.
+	Submitted int64
+	// Succeeded is the number of jobs completed without an error.
+	Succeeded int64
+	// Failed is the number of jobs that still returned an error after their retries, or were cancelled while running.
+	Failed int64
+	// Retried is the number of attempts executed after a failed attempt.
+	Retried int64
+	// Dropped is the number of accepted jobs that are never executed.
+	Dropped int64
+	// Panicked is the number of attempts that panicked.
+	Panicked int64
+	// BusyTime is the cumulative time spent executing jobs.
+	BusyTime time.Duration
+	// QueueWait is the time from the submission of a job to its start. The wait of a delayed job starts at its due time.
+	QueueWait Histogram
+	// AttemptTime is the execution time of every attempt of a job.
+	AttemptTime Histogram
+	// ExecutionTime is the total execution time of a job including its retries.
+	ExecutionTime Histogram
+}
+
+// counters hold the statistics of a JobManager. It is always allocated on its own and only has int64 fields, so they
+// are 64-bit aligned for the atomic operations on 32-bit platforms.
+type counters struct {
+	queued    int64
+	running   int64
+	submitted int64
+	succeeded int64
+	failed    int64
+	retried   int64
+	dropped   int64
+	panicked  int64
+	busy      int64
+	queueWait histogram
+	attempt   histogram
+	execution histogram
+}
+
+func (c *counters) snapshot() Stats {
+	return Stats{
+		Queued:    atomic.LoadInt64(&c.queued),
+		Running:   atomic.LoadInt64(&c.running),
+		Submitted: atomic.LoadInt64(&c.submitted),
+		Succeeded: atomic.LoadInt64(&c.succeeded),
+		Failed:    atomic.LoadInt64(&c.failed),
+		Retried:   atomic.LoadInt64(&c.retried),
+		Dropped:   atomic.LoadInt64(&c.dropped),
+		Panicked:  atomic.LoadInt64(&c.panicked),
+		BusyTime:  time.Duration(atomic.LoadInt64(&c.busy)),
+
+		QueueWait:     c.queueWait.snapshot(),
+		AttemptTime:   c.attempt.snapshot(),
+		ExecutionTime: c.execution.snapshot(),
+	}
+}
@@ -0,0 +1,56 @@
+package internal
+
+import "context"
+
+// Tracer is called around the execution of a job, so a job can be traced across the worker boundary. The context
+// returned by a hook is passed to the following hooks of the same job, and the values of the context returned by
+// AttemptStart are visible to a job submitted with DoHandle.
+type Tracer interface {
+	// Submit is called when a job is submitted with the context of the submitter and the label of the job.
+	Submit(ctx context.Context, label string) context.Context
+
+	// Start is called when a worker starts the job with the context returned by Submit.
+	Start(ctx context.Context) context.Context
+
+	// AttemptStart is called before every attempt of the job with the context returned by Start. The first attempt
+	// is 0.
+	AttemptStart(ctx context.Context, attempt int) context.Context
+
+	// AttemptEnd is called after every attempt of the job with the context returned by AttemptStart and the error
+	// of the attempt.
+	AttemptEnd(ctx context.Context, attempt int, err error)
+
+	// Retry is called before the attempt is executed again with the context returned by Start and the error of
+	// the previous attempt.
+	Retry(ctx context.Context, attempt int, err error)
+
+	// Finish is called when the job is completed with the context returned by Start and the error of the job.
+	Finish(ctx context.Context, err error)
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Submit(ctx context.Context, label string) context.Context      { return ctx }
+func (noopTracer) Start(ctx context.Context) context.Context                     { return ctx }
+func (noopTracer) AttemptStart(ctx context.Context, attempt int) context.Context { return ctx }
+func (noopTracer) AttemptEnd(ctx context.Context, attempt int, err error)        {}
+func (noopTracer) Retry(ctx context.Context, attempt int, err error)             {}
+func (noopTracer) Finish(ctx context.Context, err error)                         {}
+
+// valuesCtx is cancelled with its Context, while its values are looked up in values.
+type valuesCtx struct {
+	context.Context
+	values context.Context
+}
+
+func (c *valuesCtx) Value(key interface{}) interface{} {
+	return c.values.Value(key)
+}
+
+// withValues return a context that is cancelled with ctx and carries the values of values.
+func withValues(ctx context.Context, values context.Context) context.Context {
+	if values == context.Background() {
+		return ctx
+	}
+	return &valuesCtx{Context: ctx, values: values}
+}
@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+type traceKey struct{}
+
+type recordTracer struct {
+	mu    *sync.Mutex
+	calls []string
+}
+
+func (r *recordTracer) record(ctx context.Context, call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, fmt.Sprintf("%v:%s", ctx.Value(traceKey{}), call))
+}
+
+func (r *recordTracer) Submit(ctx context.Context, label string) context.Context {
+	r.record(ctx, "submit "+label)
+	return context.WithValue(ctx, traceKey{}, "submitted")
+}
+
+func (r *recordTracer) Start(ctx context.Context) context.Context {
+	r.record(ctx, "start")
+	return context.WithValue(ctx, traceKey{}, "started")
+}
+
+func (r *recordTracer) AttemptStart(ctx context.Context, attempt int) context.Context {
+	r.record(ctx, fmt.Sprintf("attempt start %d", attempt))
+	return context.WithValue(ctx, traceKey{}, fmt.Sprintf("attempt %d", attempt))
+}
+
+func (r *recordTracer) AttemptEnd(ctx context.Context, attempt int, err error) {
+	r.record(ctx, fmt.Sprintf("attempt end %d %v", attempt, err))
+}
+
+func (r *recordTracer) Retry(ctx context.Context, attempt int, err error) {
+	r.record(ctx, fmt.Sprintf("retry %d %v", attempt, err))
+}
+
+func (r *recordTracer) Finish(ctx context.Context, err error) {
+	r.record(ctx, fmt.Sprintf("finish %v", err))
+}
+
+func TestTracer(t *testing.T) {
+	tests := []struct {
+		name      string
+		runner    func(jm *JobManager)
+		wantCalls []string
+	}{
+		{
+			name: "test job with retry",
+			runner: func(jm *JobManager) {
+				var attempts int
+				jm.NewJob(func() error {
+					if attempts++; attempts == 1 {
+						return errors.New("an error")
+					}
+					return nil
+				})()
+			},
+			wantCalls: []string{
+				"<nil>:submit ",
+				"submitted:start",
+				"started:attempt start 0",
+				"attempt 0:attempt end 0 an error",
+				"started:retry 1 an error",
+				"started:attempt start 1",
+				"attempt 1:attempt end 1 <nil>",
+				"started:finish <nil>",
+			},
+		},
+		{
+			name: "test job handle with context",
+			runner: func(jm *JobManager) {
+				ctx := context.WithValue(context.Background(), traceKey{}, "parent")
+				pendingJob, _ := jm.NewJobHandle(func(ctx context.Context) error {
+					// The job sees the values of its attempt
+					assert.Equal(t, "attempt 0", ctx.Value(traceKey{}))
+					return nil
+				}, &OptionJob{Label: "a", Context: ctx})
+				pendingJob()
+			},
+			wantCalls: []string{
+				"parent:submit a",
+				"submitted:start",
+				"started:attempt start 0",
+				"attempt 0:attempt end 0 <nil>",
+				"started:finish <nil>",
+			},
+		},
+		{
+			name: "test job handle cancelled before executed",
+			runner: func(jm *JobManager) {
+				pendingJob, jh := jm.NewJobHandle(func(ctx context.Context) error { return nil }, &OptionJob{})
+				jh.Cancel()
+				pendingJob()
+			},
+			wantCalls: []string{
+				"<nil>:submit ",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := &recordTracer{mu: &sync.Mutex{}}
+			jm := NewJobManager(OptionJobManager{Retry: 1, Tracer: tracer}, NewErrorManager(nil, nil))
+			tt.runner(jm)
+			jm.Wait()
+			assert.Equal(t, tt.wantCalls, tracer.calls)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"runtime"
+	"time"
+)
+
+// StuckJob describe a job that has been running longer than the threshold of a Watchdog.
+type StuckJob struct {
+	// Label is the label of the job, or empty if the job is submitted without a label.
+	Label string
+	// Worker is the index of the worker executing the job, starting from 0.
+	Worker int
+	// Elapsed is the time since the worker started the job.
+	Elapsed time.Duration
+}
+
+// Stack return a stack dump of all goroutines, including the worker executing the job. It is captured when called,
+// so it is only useful while the job is still stuck.
+func (sj StuckJob) Stack() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
+
+// Watchdog report the jobs that are running longer than a threshold. A job is reported at most once, and a nil
+// Watchdog executes jobs without watching them.
+type Watchdog struct {
+	threshold time.Duration
+	callback  func(job StuckJob)
+}
+
+// Watch execute the job on the worker, and call the callback of the Watchdog in a separate goroutine once the job
+// has been running longer than the threshold.
+func (wd *Watchdog) Watch(worker int, label string, job PendingJob) {
+	if wd == nil {
+		job()
+		return
+	}
+	start := time.Now()
+	t := time.AfterFunc(wd.threshold, func() {
+		wd.callback(StuckJob{Label: label, Worker: worker, Elapsed: time.Since(start)})
+	})
+	defer t.Stop()
+	job()
+}
+
+// NewWatchdog create a new Watchdog. It returns nil if the threshold is not positive or the callback is nil.
+func NewWatchdog(threshold time.Duration, callback func(job StuckJob)) *Watchdog {
+	if threshold <= 0 || callback == nil {
+		return nil
+	}
+	return &Watchdog{
+		threshold: threshold,
+		callback:  callback,
+	}
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	type args struct {
+		threshold time.Duration
+		callback  bool
+	}
+	tests := []struct {
+		name      string
+		args      args
+		duration  time.Duration
+		wantStuck []StuckJob
+	}{
+		{
+			name: "test use default value",
+			args: args{
+				threshold: 0,
+				callback:  true,
+			},
+			duration:  time.Millisecond * 200,
+			wantStuck: nil,
+		},
+		{
+			name: "test without callback",
+			args: args{
+				threshold: time.Millisecond * 100,
+				callback:  false,
+			},
+			duration:  time.Millisecond * 200,
+			wantStuck: nil,
+		},
+		{
+			name: "test job completed in time",
+			args: args{
+				threshold: time.Millisecond * 200,
+				callback:  true,
+			},
+			duration:  time.Millisecond * 100,
+			wantStuck: nil,
+		},
+		{
+			name: "test job stuck",
+			args: args{
+				threshold: time.Millisecond * 100,
+				callback:  true,
+			},
+			duration:  time.Millisecond * 300,
+			wantStuck: []StuckJob{{Label: "a", Worker: 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu  = &sync.Mutex{}
+				got []StuckJob
+			)
+			var callback func(job StuckJob)
+			if tt.args.callback {
+				callback = func(job StuckJob) {
+					assert.LessOrEqual(t, tt.args.threshold, job.Elapsed)
+					assert.LessOrEqual(t, job.Elapsed, tt.args.threshold+(time.Millisecond*100)) // Add 0.1s as a threshold
+					assert.Contains(t, string(job.Stack()), "TestWatchdog")
+					job.Elapsed = 0
+					mu.Lock()
+					defer mu.Unlock()
+					got = append(got, job)
+				}
+			}
+			wd := NewWatchdog(tt.args.threshold, callback)
+			wd.Watch(2, "a", func() {
+				time.Sleep(tt.duration)
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, tt.wantStuck, got)
+		})
+	}
+}
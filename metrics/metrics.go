@@ -0,0 +1,183 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/bearaujus/bworker/internal"
+	"github.com/bearaujus/bworker/pool"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source is a worker whose Stats are rendered by BWorkerMetrics, such as pool.BWorkerPool, pool.BWorkerKeyedPool
+// and flex.BWorkerFlex.
+type Source interface {
+	Stats() pool.Stats
+}
+
+type BWorkerMetrics interface {
+	// Register add a Source to be rendered with the name as its pool label. It returns an error if the name is
+	// already registered or the Source is nil.
+	Register(name string, source Source) error
+
+	// Unregister remove the Source registered with the name. If the name is not registered this function will
+	// perform no-op.
+	Unregister(name string)
+
+	// ServeHTTP render the Stats of every registered Source in the Prometheus text exposition format.
+	ServeHTTP(w http.ResponseWriter, r *http.Request)
+}
+
+type bWorkerMetrics struct {
+	namespace string
+	mu        *sync.RWMutex
+	sources   map[string]Source
+}
+
+// NewBWorkerMetrics create a new BWorkerMetrics with OptionMetrics(s). It is an http.Handler to be served on
+// a path scraped by Prometheus, such as /metrics.
+func NewBWorkerMetrics(opts ...OptionMetrics) BWorkerMetrics {
+	o := &internal.OptionMetrics{Namespace: "bworker"}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt.Apply(o)
+	}
+	return &bWorkerMetrics{
+		namespace: o.Namespace,
+		mu:        &sync.RWMutex{},
+		sources:   make(map[string]Source),
+	}
+}
+
+func (bwm *bWorkerMetrics) Register(name string, source Source) error {
+	if source == nil {
+		return fmt.Errorf("source %q is nil", name)
+	}
+	bwm.mu.Lock()
+	defer bwm.mu.Unlock()
+	if _, ok := bwm.sources[name]; ok {
+		return fmt.Errorf("source %q is already registered", name)
+	}
+	bwm.sources[name] = source
+	return nil
+}
+
+func (bwm *bWorkerMetrics) Unregister(name string) {
+	bwm.mu.Lock()
+	defer bwm.mu.Unlock()
+	delete(bwm.sources, name)
+}
+
+func (bwm *bWorkerMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = bwm.write(w)
+}
+
+// snapshot is the Stats of a Source taken at the beginning of a scrape, so every metric of a scrape is consistent.
+type snapshot struct {
+	name  string
+	stats pool.Stats
+}
+
+// metric describe a metric family and how to read its value from the Stats.
+type metric struct {
+	name  string
+	kind  string
+	help  string
+	value func(s pool.Stats) float64
+}
+
+var metrics = []metric{
+	{"jobs_queued", "gauge", "Number of submitted jobs waiting to be executed.",
+		func(s pool.Stats) float64 { return float64(s.Queued) }},
+	{"jobs_running", "gauge", "Number of jobs being executed.",
+		func(s pool.Stats) float64 { return float64(s.Running) }},
+	{"workers_idle", "gauge", "Number of workers waiting for a job.",
+		func(s pool.Stats) float64 { return float64(s.IdleWorkers) }},
+	{"jobs_submitted_total", "counter", "Total number of accepted jobs.",
+		func(s pool.Stats) float64 { return float64(s.Submitted) }},
+	{"jobs_succeeded_total", "counter", "Total number of jobs completed without an error.",
+		func(s pool.Stats) float64 { return float64(s.Succeeded) }},
+	{"jobs_failed_total", "counter", "Total number of jobs that failed after their retries.",
+		func(s pool.Stats) float64 { return float64(s.Failed) }},
+	{"jobs_retried_total", "counter", "Total number of attempts executed after a failed attempt.",
+		func(s pool.Stats) float64 { return float64(s.Retried) }},
+	{"jobs_dropped_total", "counter", "Total number of accepted jobs that are never executed.",
+		func(s pool.Stats) float64 { return float64(s.Dropped) }},
+	{"jobs_panicked_total", "counter", "Total number of attempts that panicked.",
+		func(s pool.Stats) float64 { return float64(s.Panicked) }},
+	{"busy_seconds_total", "counter", "Total time spent executing jobs in seconds.",
+		func(s pool.Stats) float64 { return s.BusyTime.Seconds() }},
+}
+
+// summary describe a summary family and how to read its Histogram from the Stats.
+type summary struct {
+	name      string
+	help      string
+	histogram func(s pool.Stats) pool.Histogram
+}
+
+var summaries = []summary{
+	{"job_queue_wait_seconds", "Time from the submission or the due time of a job to its start in seconds.",
+		func(s pool.Stats) pool.Histogram { return s.QueueWait }},
+	{"job_attempt_seconds", "Execution time of every attempt of a job in seconds.",
+		func(s pool.Stats) pool.Histogram { return s.AttemptTime }},
+	{"job_execution_seconds", "Total execution time of a job including its retries in seconds.",
+		func(s pool.Stats) pool.Histogram { return s.ExecutionTime }},
+}
+
+// write render the Stats of every registered Source sorted by name.
+func (bwm *bWorkerMetrics) write(w io.Writer) error {
+	bwm.mu.RLock()
+	snapshots := make([]snapshot, 0, len(bwm.sources))
+	for name, source := range bwm.sources {
+		snapshots = append(snapshots, snapshot{name: name, stats: source.Stats()})
+	}
+	bwm.mu.RUnlock()
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].name < snapshots[j].name
+	})
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		name := bwm.namespace + "_" + m.name
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", name, m.help, name, m.kind)
+		for _, s := range snapshots {
+			fmt.Fprintf(bw, "%s{pool=\"%s\"} %s\n", name, escape(s.name), formatFloat(m.value(s.stats)))
+		}
+	}
+	for _, m := range summaries {
+		name := bwm.namespace + "_" + m.name
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s summary\n", name, m.help, name)
+		for _, s := range snapshots {
+			h, label := m.histogram(s.stats), escape(s.name)
+			for _, q := range []struct {
+				quantile string
+				value    time.Duration
+			}{{"0.5", h.P50}, {"0.9", h.P90}, {"0.99", h.P99}} {
+				fmt.Fprintf(bw, "%s{pool=\"%s\",quantile=\"%s\"} %s\n", name, label, q.quantile, formatFloat(q.value.Seconds()))
+			}
+			fmt.Fprintf(bw, "%s_sum{pool=\"%s\"} %s\n", name, label, formatFloat(h.Sum.Seconds()))
+			fmt.Fprintf(bw, "%s_count{pool=\"%s\"} %d\n", name, label, h.Count)
+		}
+	}
+	return bw.Flush()
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escape a label value as required by the Prometheus text exposition format.
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/bearaujus/bworker/flex"
+	"github.com/bearaujus/bworker/pool"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeSource struct{ stats pool.Stats }
+
+func (f *fakeSource) Stats() pool.Stats {
+	return f.stats
+}
+
+func TestMetrics(t *testing.T) {
+	type args struct {
+		opts []OptionMetrics
+	}
+	tests := []struct {
+		name         string
+		args         args
+		register     func(bwm BWorkerMetrics)
+		wantLines    []string
+		notWantLines []string
+	}{
+		{
+			name: "test use default value",
+			args: args{
+				opts: []OptionMetrics{WithNamespace(""), nil},
+			},
+			register: func(bwm BWorkerMetrics) {},
+			wantLines: []string{
+				"# HELP bworker_jobs_queued Number of submitted jobs waiting to be executed.",
+				"# TYPE bworker_jobs_queued gauge",
+				"# TYPE bworker_jobs_submitted_total counter",
+				"# TYPE bworker_job_queue_wait_seconds summary",
+			},
+			notWantLines: []string{
+				`bworker_jobs_queued{pool="a"} 0`,
+			},
+		},
+		{
+			name: "test render stats",
+			args: args{
+				opts: nil,
+			},
+			register: func(bwm BWorkerMetrics) {
+				assert.NoError(t, bwm.Register("a", &fakeSource{pool.Stats{
+					Queued:      3,
+					Running:     2,
+					IdleWorkers: 1,
+					Submitted:   10,
+					Succeeded:   4,
+					Failed:      1,
+					Retried:     5,
+					Dropped:     0,
+					Panicked:    2,
+					BusyTime:    time.Millisecond * 1500,
+					QueueWait: pool.Histogram{Count: 5, Sum: time.Second, Max: time.Second,
+						P50: time.Millisecond, P90: time.Millisecond * 100, P99: time.Second},
+				}}))
+			},
+			wantLines: []string{
+				`bworker_jobs_queued{pool="a"} 3`,
+				`bworker_jobs_running{pool="a"} 2`,
+				`bworker_workers_idle{pool="a"} 1`,
+				`bworker_jobs_submitted_total{pool="a"} 10`,
+				`bworker_jobs_succeeded_total{pool="a"} 4`,
+				`bworker_jobs_failed_total{pool="a"} 1`,
+				`bworker_jobs_retried_total{pool="a"} 5`,
+				`bworker_jobs_dropped_total{pool="a"} 0`,
+				`bworker_jobs_panicked_total{pool="a"} 2`,
+				`bworker_busy_seconds_total{pool="a"} 1.5`,
+				`bworker_job_queue_wait_seconds{pool="a",quantile="0.5"} 0.001`,
+				`bworker_job_queue_wait_seconds{pool="a",quantile="0.9"} 0.1`,
+				`bworker_job_queue_wait_seconds{pool="a",quantile="0.99"} 1`,
+				`bworker_job_queue_wait_seconds_sum{pool="a"} 1`,
+				`bworker_job_queue_wait_seconds_count{pool="a"} 5`,
+				`bworker_job_execution_seconds_count{pool="a"} 0`,
+			},
+			notWantLines: nil,
+		},
+		{
+			name: "test render pools and flex with namespace",
+			args: args{
+				opts: []OptionMetrics{WithNamespace("app")},
+			},
+			register: func(bwm BWorkerMetrics) {
+				bwp := pool.NewBWorkerPool(2)
+				defer bwp.Shutdown()
+				bwp.Do(func() error { return nil })
+				bwp.Do(func() error { return errors.New("an error") })
+				bwp.Wait()
+				bwf := flex.NewBWorkerFlex()
+				bwf.DoSimple(func() {})
+				bwf.Wait()
+				assert.NoError(t, bwm.Register(`b "x"`, bwp))
+				assert.NoError(t, bwm.Register("a\n", bwf))
+			},
+			wantLines: []string{
+				`app_jobs_submitted_total{pool="a\n"} 1`,
+				`app_jobs_submitted_total{pool="b \"x\""} 2`,
+				`app_jobs_failed_total{pool="b \"x\""} 1`,
+				// The pool is shut down before it is rendered
+				`app_workers_idle{pool="b \"x\""} 0`,
+			},
+			notWantLines: []string{
+				"# TYPE bworker_jobs_queued gauge",
+			},
+		},
+		{
+			name: "test register and unregister",
+			args: args{
+				opts: nil,
+			},
+			register: func(bwm BWorkerMetrics) {
+				assert.Error(t, bwm.Register("a", nil))
+				assert.NoError(t, bwm.Register("a", &fakeSource{pool.Stats{Queued: 1}}))
+				assert.Error(t, bwm.Register("a", &fakeSource{pool.Stats{Queued: 2}}))
+				assert.NoError(t, bwm.Register("b", &fakeSource{pool.Stats{Queued: 3}}))
+				bwm.Unregister("b")
+				bwm.Unregister("c")
+			},
+			wantLines: []string{
+				`bworker_jobs_queued{pool="a"} 1`,
+			},
+			notWantLines: []string{
+				`bworker_jobs_queued{pool="a"} 2`,
+				`bworker_jobs_queued{pool="b"} 3`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bwm := NewBWorkerMetrics(tt.args.opts...)
+			tt.register(bwm)
+			rec := httptest.NewRecorder()
+			bwm.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+			assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+			lines := strings.Split(rec.Body.String(), "\n")
+			for _, want := range tt.wantLines {
+				assert.Contains(t, lines, want)
+			}
+			for _, notWant := range tt.notWantLines {
+				assert.NotContains(t, lines, notWant)
+			}
+		})
+	}
+}
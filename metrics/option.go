@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"github.com/bearaujus/bworker/internal"
+)
+
+type OptionMetrics interface {
+	Apply(o *internal.OptionMetrics)
+}
+
+// WithNamespace set the prefix of every metric name. If you're not using this option, the default namespace is
+// bworker, for example bworker_jobs_queued.
+func WithNamespace(namespace string) OptionMetrics {
+	return &withNamespace{namespace}
+}
+
+type withNamespace struct{ namespace string }
+
+func (w *withNamespace) Apply(o *internal.OptionMetrics) {
+	if w.namespace == "" {
+		return
+	}
+	o.Namespace = w.namespace
+}
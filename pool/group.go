@@ -0,0 +1,99 @@
+package pool
+
+import "github.com/bearaujus/bworker/internal"
+
+type BWorkerGroup interface {
+	// Do submit a job of the group to be executed by a worker of the pool. If the pool IsDead the job is dropped and
+	// ErrPoolClosed is reported to the group. This function may block the thread the same way as BWorkerPool.Do.
+	Do(job func() error)
+
+	// DoSimple submit a job of the group to be executed by a worker of the pool without an error. If the pool IsDead
+	// the job is dropped and ErrPoolClosed is reported to the group. This function may block the thread the same way
+	// as BWorkerPool.DoSimple.
+	DoSimple(job func())
+
+	// Wait wait for all jobs of the group to be completed. It does not wait for the other jobs of the pool.
+	Wait()
+
+	// Err return the error of the latest failed job of the group, or nil if no job fails.
+	Err() error
+
+	// Errs return the errors of all failed jobs of the group.
+	Errs() []error
+
+	// ClearErr reset the error returned by Err.
+	ClearErr()
+
+	// ClearErrs reset the errors returned by Errs.
+	ClearErrs()
+}
+
+type bWorkerGroup struct {
+	bwp          *bWorkerPool
+	jobManager   *internal.JobManager
+	errorManager *internal.ErrorManager
+	err          error
+	errs         []error
+}
+
+func (bwp *bWorkerPool) Group() BWorkerGroup {
+	bwg := &bWorkerGroup{bwp: bwp}
+	bwg.errorManager = internal.NewErrorManager(&bwg.err, &bwg.errs)
+	bwg.jobManager = bwp.jobManager.NewGroup(bwg.errorManager)
+	return bwg
+}
+
+func (bwg *bWorkerGroup) Do(job func() error) {
+	if job == nil {
+		return
+	}
+	bwg.submit(func() internal.PendingJob {
+		return bwg.jobManager.NewJob(job)
+	})
+}
+
+func (bwg *bWorkerGroup) DoSimple(job func()) {
+	if job == nil {
+		return
+	}
+	bwg.submit(func() internal.PendingJob {
+		return bwg.jobManager.NewJobSimple(job)
+	})
+}
+
+// submit create a job with newJob and submit it to the pool. The job manager of the group shares the Stats, Hooks and
+// Tracer of the pool and handles the retries and errors, while the job manager of the pool only tracks the job, so
+// BWorkerPool.Wait also waits for the jobs of the group. ErrPoolClosed is reported to the group if the job is dropped.
+func (bwg *bWorkerGroup) submit(newJob func() internal.PendingJob) {
+	bwp := bwg.bwp
+	if !bwp.ctxManager.Enter() {
+		bwg.errorManager.SetIfNotNil(ErrPoolClosed)
+		return
+	}
+	defer bwp.ctxManager.Leave()
+	if !bwp.scheduler.PushLabeled(bwp.jobManager.Track(newJob()), "", internal.DefaultClass, 0, 1) {
+		bwp.jobManager.Untrack()
+		bwg.jobManager.Done(ErrPoolClosed)
+		bwg.errorManager.SetIfNotNil(ErrPoolClosed)
+	}
+}
+
+func (bwg *bWorkerGroup) Wait() {
+	bwg.jobManager.Wait()
+}
+
+func (bwg *bWorkerGroup) Err() error {
+	return bwg.errorManager.Err()
+}
+
+func (bwg *bWorkerGroup) Errs() []error {
+	return bwg.errorManager.Errs()
+}
+
+func (bwg *bWorkerGroup) ClearErr() {
+	bwg.errorManager.ClearErr()
+}
+
+func (bwg *bWorkerGroup) ClearErrs() {
+	bwg.errorManager.ClearErrs()
+}
@@ -0,0 +1,278 @@
+package pool
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGroup(t *testing.T) {
+	type args struct {
+		concurrency int
+		opts        []OptionPool
+	}
+	var (
+		hookMu = &sync.Mutex{}
+		hooked = make(map[string]int)
+	)
+	hook := func(event string) func(JobInfo) {
+		return func(JobInfo) {
+			hookMu.Lock()
+			defer hookMu.Unlock()
+			hooked[event]++
+		}
+	}
+	tests := []struct {
+		name        string
+		args        args
+		jobs        func(bwp BWorkerPool, bwg BWorkerGroup) *int64
+		wantRet     int64
+		wantErr     bool
+		wantErrsLen int
+	}{
+		{
+			name: "test execute nil job",
+			args: args{
+				concurrency: 10,
+				opts:        nil,
+			},
+			jobs: func(bwp BWorkerPool, bwg BWorkerGroup) *int64 {
+				var ret int64
+
+				bwg.Do(nil)
+				bwg.DoSimple(nil)
+				return &ret
+			},
+			wantRet:     0,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test execute jobs when already shut down",
+			args: args{
+				concurrency: 10,
+				opts:        nil,
+			},
+			jobs: func(bwp BWorkerPool, bwg BWorkerGroup) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				bwp.Shutdown()
+				bwg.Do(func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return nil
+				})
+				bwg.DoSimple(func() {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+				})
+				// The dropped jobs are reported to the group
+				assert.ErrorIs(t, bwg.Err(), ErrPoolClosed)
+				assert.Len(t, bwg.Errs(), 2)
+				return &ret
+			},
+			wantRet:     0,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test execute jobs with retry",
+			args: args{
+				concurrency: 10,
+				opts:        []OptionPool{WithRetry(3), WithError(nil), WithErrors(nil)}, // Error will be masked at runner
+			},
+			jobs: func(bwp BWorkerPool, bwg BWorkerGroup) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				numJob, wantErrLen := 200, 50
+				for i := 0; i < numJob; i++ {
+					icp := i
+					bwg.Do(func() error {
+						mu.Lock()
+						defer mu.Unlock()
+						ret++
+						if icp < wantErrLen {
+							return errors.New("an error")
+						}
+						return nil
+					})
+					bwg.DoSimple(func() {
+						mu.Lock()
+						defer mu.Unlock()
+						ret++
+					})
+				}
+				bwg.Wait()
+				// The errors are reported by the group instead of the pool
+				assert.Error(t, bwg.Err())
+				assert.Len(t, bwg.Errs(), wantErrLen)
+				bwg.ClearErr()
+				bwg.ClearErrs()
+				assert.NoError(t, bwg.Err())
+				assert.Empty(t, bwg.Errs())
+				return &ret
+			},
+			wantRet:     (50 * (1 + 3)) + 150 + 200, // (wantErrLen*(1+numRetry)) + doSuccessLen + doSimple
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test wait for the jobs of the group only",
+			args: args{
+				concurrency: 2,
+				opts:        nil,
+			},
+			jobs: func(bwp BWorkerPool, bwg BWorkerGroup) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				start := time.Now()
+				bwp.DoSimple(func() {
+					time.Sleep(time.Second)
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+				})
+				bwg.DoSimple(func() {
+					time.Sleep(time.Millisecond * 200)
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+				})
+				// The total block time should be around ~200 ms
+				bwg.Wait()
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Millisecond*200, ts)
+				assert.LessOrEqual(t, ts, (time.Millisecond*200)+(time.Millisecond*100)) // Add 0.1s as a threshold
+				// The pool waits for the jobs of the group as well
+				other := bwp.Group()
+				other.DoSimple(func() {
+					time.Sleep(time.Millisecond * 200)
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+				})
+				bwp.Wait()
+				ts = time.Since(start)
+				assert.LessOrEqual(t, time.Second, ts)
+				assert.LessOrEqual(t, ts, time.Second+(time.Millisecond*100)) // Add 0.1s as a threshold
+				return &ret
+			},
+			wantRet:     3,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test share the concurrency of the pool",
+			args: args{
+				concurrency: 2,
+				opts:        nil,
+			},
+			jobs: func(bwp BWorkerPool, bwg BWorkerGroup) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				start := time.Now()
+				other := bwp.Group()
+				for i := 0; i < 2; i++ {
+					for _, g := range []BWorkerGroup{bwg, other} {
+						g.DoSimple(func() {
+							time.Sleep(time.Millisecond * 300)
+							mu.Lock()
+							defer mu.Unlock()
+							ret++
+						})
+					}
+				}
+				bwg.Wait()
+				other.Wait()
+				// 4 jobs with 2 workers, the total executed time should be around ~600 ms
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Millisecond*600, ts)
+				assert.LessOrEqual(t, ts, (time.Millisecond*600)+(time.Millisecond*100)) // Add 0.1s as a threshold
+				return &ret
+			},
+			wantRet:     4,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test count the jobs in the stats and hooks of the pool",
+			args: args{
+				concurrency: 2,
+				opts: []OptionPool{WithRetry(1), WithError(nil), WithErrors(nil), WithHooks(Hooks{
+					OnSuccess: hook("success"),
+					OnFailure: hook("failure"),
+					OnRetry:   hook("retry"),
+				})},
+			},
+			jobs: func(bwp BWorkerPool, bwg BWorkerGroup) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				bwg.Do(func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return errors.New("an error")
+				})
+				bwg.DoSimple(func() {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+				})
+				bwg.Wait()
+				assert.Error(t, bwg.Err())
+				stats := bwp.Stats()
+				assert.Equal(t, int64(2), stats.Submitted)
+				assert.Equal(t, int64(1), stats.Succeeded)
+				assert.Equal(t, int64(1), stats.Failed)
+				assert.Equal(t, int64(1), stats.Retried)
+				hookMu.Lock()
+				assert.Equal(t, map[string]int{"success": 1, "failure": 1, "retry": 1}, hooked)
+				hookMu.Unlock()
+				return &ret
+			},
+			wantRet:     (1 + 1) + 1, // (base attempt + num retry) + not failed
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				err  error
+				errs []error
+			)
+			for _, opt := range tt.args.opts {
+				switch o := opt.(type) {
+				case *withError:
+					o.e = &err
+				case *withErrors:
+					o.es = &errs
+				}
+			}
+			bwp := NewBWorkerPool(tt.args.concurrency, tt.args.opts...)
+			defer bwp.Shutdown()
+			bwg := bwp.Group()
+			if tt.jobs != nil {
+				gotNumExecuted := tt.jobs(bwp, bwg)
+				bwg.Wait()
+				bwp.Wait()
+				assert.Equal(t, tt.wantRet, *gotNumExecuted)
+			}
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantErrsLen, len(errs))
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package pool
+
+import (
+	"github.com/bearaujus/bworker/internal"
+	"sync"
+)
+
+type BWorkerKeyedPool interface {
+	// Do submit a job to be executed by a worker. Jobs that share the same key are executed one at a time in
+	// submission order, while jobs with different keys are executed concurrently. If IsDead the job is dropped and
+	// ErrPoolClosed is reported to WithError and WithErrors. This function may block the thread while the key already
+	// has as many jobs waiting as the concurrency level.
+	Do(key string, job func() error)
+
+	// DoSimple submit a job to be executed by a worker without an error. Jobs that share the same key are executed
+	// one at a time in submission order, while jobs with different keys are executed concurrently.
+	// If IsDead the job is dropped and ErrPoolClosed is reported to WithError and WithErrors. This function may block
+	// the thread while the key already has as many jobs waiting as the concurrency level.
+	DoSimple(key string, job func())
+
+	// Wait wait for all jobs to be completed. If IsDead this function will perform no-op.
+	Wait()
+
+	// Shutdown shut down the worker pool. After performing this operation, submitted jobs are dropped.
+	// If IsDead this function will perform no-op.
+	Shutdown()
+
+	// IsDead indicates the BWorkerKeyedPool is already shut down or not.
+	IsDead() bool
+
+	// Name return the name of the BWorkerKeyedPool set with WithName, or empty if it is not set.
+	Name() string
+
+	// Stats return a snapshot of the runtime statistics of the BWorkerKeyedPool. A job waiting for another job with
+	// the same key is counted as queued.
+	Stats() Stats
+
+	// ClearErr reset the error variable when you are using WithErrors.
+	ClearErr()
+
+	// ClearErrs reset the slice of error variables when you are using WithErrors.
+	ClearErrs()
+}
+
+type bWorkerKeyedPool struct {
+	*bWorkerPool
+	mu *sync.Mutex
+	// queues holds the jobs waiting behind the running job of each key. A key is present while one of its
+	// jobs is queued or being executed by a worker.
+	queues map[string][]internal.PendingJob
+	// notFull is signaled when a job leaves the queue of a key, so a submitter blocked by a full key can continue.
+	notFull *sync.Cond
+}
+
+// NewKeyedPool create a new BWorkerKeyedPool with OptionPool(s) and specified concurrency level.
+//
+// A key occupies at most one worker at a time, so the number of keys being processed concurrently is
+// bounded by the concurrency level. A worker executes one job of a key at a time, and the next job of the key is
+// queued behind the jobs of the other keys, so a busy key does not starve the others. Do and DoSimple block while
+// the key already has as many jobs waiting as the concurrency level.
+//
+// Please use BWorkerKeyedPool.Shutdown() to avoid memory leak from the unclosed channel(s).
+func NewKeyedPool(concurrency int, opts ...OptionPool) BWorkerKeyedPool {
+	bwkp := &bWorkerKeyedPool{
+		bWorkerPool: newBWorkerPool(nil, concurrency, opts...),
+		mu:          &sync.Mutex{},
+		queues:      make(map[string][]internal.PendingJob),
+	}
+	bwkp.notFull = sync.NewCond(bwkp.mu)
+	return bwkp
+}
+
+func (bwkp *bWorkerKeyedPool) Do(key string, job func() error) {
+	if job == nil {
+		return
+	}
+	bwkp.submit(key, func() internal.PendingJob {
+		return bwkp.jobManager.NewJob(job)
+	})
+}
+
+func (bwkp *bWorkerKeyedPool) DoSimple(key string, job func()) {
+	if job == nil {
+		return
+	}
+	bwkp.submit(key, func() internal.PendingJob {
+		return bwkp.jobManager.NewJobSimple(job)
+	})
+}
+
+// submit create a job with newJob while the pool is alive and enqueue it, or report ErrPoolClosed otherwise.
+func (bwkp *bWorkerKeyedPool) submit(key string, newJob func() internal.PendingJob) {
+	if !bwkp.ctxManager.Enter() {
+		bwkp.errorManager.SetIfNotNil(ErrPoolClosed)
+		return
+	}
+	defer bwkp.ctxManager.Leave()
+	bwkp.enqueue(key, newJob())
+}
+
+func (bwkp *bWorkerKeyedPool) enqueue(key string, pendingJob internal.PendingJob) {
+	bwkp.mu.Lock()
+	if _, ok := bwkp.queues[key]; ok {
+		// Another job with the same key is queued or running, it queues this job once it is completed
+		for len(bwkp.queues[key]) >= bwkp.size() {
+			bwkp.notFull.Wait()
+		}
+		if q, ok := bwkp.queues[key]; ok {
+			bwkp.queues[key] = append(q, pendingJob)
+			bwkp.mu.Unlock()
+			return
+		}
+	}
+	bwkp.queues[key] = nil
+	bwkp.mu.Unlock()
+	bwkp.push(key, pendingJob, true)
+}
+
+// size return the maximum number of jobs waiting in the queue of a key, the current concurrency level.
+func (bwkp *bWorkerKeyedPool) size() int {
+	bwkp.bWorkerPool.mu.Lock()
+	defer bwkp.bWorkerPool.mu.Unlock()
+	return bwkp.concurrency
+}
+
+// push queue a runner executing pendingJob of the key to the scheduler, waiting for room in the job pool if wait is
+// true. If the pool is shut down, every job of the key is dropped since there is no worker to execute them.
+func (bwkp *bWorkerKeyedPool) push(key string, pendingJob internal.PendingJob, wait bool) {
+	runner := func() {
+		bwkp.run(key, pendingJob)
+	}
+	var pushed bool
+	if wait {
+		pushed = bwkp.scheduler.Push(runner, internal.DefaultClass, 0, 1)
+	} else {
+		pushed = bwkp.scheduler.PushNoWait(runner, "", internal.DefaultClass, 0, 1)
+	}
+	if pushed {
+		return
+	}
+	bwkp.mu.Lock()
+	q := bwkp.queues[key]
+	delete(bwkp.queues, key)
+	bwkp.notFull.Broadcast()
+	bwkp.mu.Unlock()
+	for i := 0; i <= len(q); i++ {
+		bwkp.jobManager.Done(ErrPoolClosed)
+		bwkp.errorManager.SetIfNotNil(ErrPoolClosed)
+	}
+}
+
+// run execute pendingJob, then queue the next job of the key behind the jobs of the other keys, so a busy key does
+// not hold the worker. The next job is queued without waiting for room in the job pool, since a worker must not be
+// blocked by the submitters.
+func (bwkp *bWorkerKeyedPool) run(key string, pendingJob internal.PendingJob) {
+	pendingJob()
+	bwkp.mu.Lock()
+	q := bwkp.queues[key]
+	if len(q) == 0 {
+		delete(bwkp.queues, key)
+		bwkp.mu.Unlock()
+		return
+	}
+	next := q[0]
+	q[0] = nil
+	bwkp.queues[key] = q[1:]
+	bwkp.notFull.Broadcast()
+	bwkp.mu.Unlock()
+	bwkp.push(key, next, false)
+}
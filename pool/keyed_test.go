@@ -0,0 +1,342 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeyedPool(t *testing.T) {
+	type args struct {
+		concurrency int
+		opts        []OptionPool
+	}
+	tests := []struct {
+		name        string
+		args        args
+		jobs        func(bwkp BWorkerKeyedPool) *int64
+		wantRet     int64
+		wantErr     bool
+		wantErrsLen int
+	}{
+		{
+			name: "test execute nil job",
+			args: args{
+				concurrency: 10,
+				opts:        nil,
+			},
+			jobs: func(bwkp BWorkerKeyedPool) *int64 {
+				var ret int64
+
+				bwkp.Do("a", nil)
+				bwkp.DoSimple("a", nil)
+				return &ret
+			},
+			wantRet:     0,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test execute jobs when already shut down",
+			args: args{
+				concurrency: 10,
+				opts:        nil,
+			},
+			jobs: func(bwkp BWorkerKeyedPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				bwkp.Shutdown()
+				bwkp.Do("a", func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return nil
+				})
+				bwkp.DoSimple("a", func() {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+				})
+				return &ret
+			},
+			wantRet:     0,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test report closed pool",
+			args: args{
+				concurrency: 10,
+				opts:        []OptionPool{WithError(nil), WithErrors(nil)},
+			},
+			jobs: func(bwkp BWorkerKeyedPool) *int64 {
+				var ret int64
+
+				bwkp.Shutdown()
+				bwkp.Do("a", func() error { return nil })
+				bwkp.DoSimple("a", func() {})
+				return &ret
+			},
+			wantRet:     0,
+			wantErr:     true,
+			wantErrsLen: 2,
+		},
+		{
+			name: "test stats",
+			args: args{
+				concurrency: 2,
+				opts:        nil,
+			},
+			jobs: func(bwkp BWorkerKeyedPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				release := make(chan struct{})
+				started := make(chan struct{})
+				bwkp.DoSimple("a", func() {
+					close(started)
+					<-release
+				})
+				<-started
+				bwkp.DoSimple("a", func() {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+				})
+				stats := bwkp.Stats()
+				assert.Equal(t, int64(1), stats.Queued)
+				assert.Equal(t, int64(1), stats.Running)
+				assert.Equal(t, int64(1), stats.IdleWorkers)
+				close(release)
+				bwkp.Wait()
+				assert.Equal(t, int64(2), bwkp.Stats().Succeeded)
+				return &ret
+			},
+			wantRet:     1,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test same key executed in order and never concurrently",
+			args: args{
+				concurrency: 10,
+				opts:        nil,
+			},
+			jobs: func(bwkp BWorkerKeyedPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				numKey, numJob := 5, 200
+				got := make(map[string][]int)
+				running := make(map[string]bool)
+				for i := 0; i < numJob; i++ {
+					for k := 0; k < numKey; k++ {
+						icp, key := i, fmt.Sprint(k)
+						bwkp.DoSimple(key, func() {
+							mu.Lock()
+							assert.False(t, running[key])
+							running[key] = true
+							mu.Unlock()
+
+							time.Sleep(time.Microsecond)
+
+							mu.Lock()
+							defer mu.Unlock()
+							running[key] = false
+							got[key] = append(got[key], icp)
+							ret++
+						})
+					}
+				}
+				bwkp.Wait()
+				for k := 0; k < numKey; k++ {
+					assert.Len(t, got[fmt.Sprint(k)], numJob)
+					for i, v := range got[fmt.Sprint(k)] {
+						assert.Equal(t, i, v)
+					}
+				}
+				return &ret
+			},
+			wantRet:     5 * 200,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test different keys executed concurrently",
+			args: args{
+				concurrency: 3,
+				opts:        nil,
+			},
+			jobs: func(bwkp BWorkerKeyedPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				start := time.Now()
+				for _, key := range []string{"a", "b", "c"} {
+					bwkp.DoSimple(key, func() {
+						time.Sleep(time.Second)
+						mu.Lock()
+						defer mu.Unlock()
+						ret++
+					})
+				}
+				bwkp.Wait()
+				// The total executed time should be around ~1 sec
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Second, ts)
+				assert.LessOrEqual(t, ts, time.Second+(time.Millisecond*100)) // Add 0.1s as a threshold
+				return &ret
+			},
+			wantRet:     3,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test busy key does not starve other keys",
+			args: args{
+				concurrency: 1,
+				opts:        nil,
+			},
+			jobs: func(bwkp BWorkerKeyedPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+				var got []string
+
+				record := func(key string) func() {
+					return func() {
+						mu.Lock()
+						defer mu.Unlock()
+						got = append(got, key)
+						ret++
+					}
+				}
+				release := make(chan struct{})
+				started := make(chan struct{})
+				bwkp.DoSimple("a", func() {
+					close(started)
+					<-release
+					record("a")()
+				})
+				<-started
+				bwkp.DoSimple("a", record("a"))
+				bwkp.DoSimple("b", record("b"))
+				close(release)
+				bwkp.Wait()
+				// The next job of "a" is queued behind "b" once the first job of "a" is completed
+				assert.Equal(t, []string{"a", "b", "a"}, got)
+				return &ret
+			},
+			wantRet:     3,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test submit blocked when the key is full",
+			args: args{
+				concurrency: 1,
+				opts:        nil,
+			},
+			jobs: func(bwkp BWorkerKeyedPool) *int64 {
+				var ret int64
+
+				release := make(chan struct{})
+				started := make(chan struct{})
+				bwkp.DoSimple("a", func() {
+					close(started)
+					<-release
+					atomic.AddInt64(&ret, 1)
+				})
+				<-started
+				// The first waiting job fits the concurrency level, the next one blocks until a job of the key leaves
+				bwkp.DoSimple("a", func() { atomic.AddInt64(&ret, 1) })
+				submitted := make(chan struct{})
+				go func() {
+					bwkp.DoSimple("a", func() { atomic.AddInt64(&ret, 1) })
+					close(submitted)
+				}()
+				select {
+				case <-submitted:
+					t.Error("DoSimple is not blocked by a full key")
+				case <-time.After(time.Millisecond * 100):
+				}
+				close(release)
+				<-submitted
+				bwkp.Wait()
+				return &ret
+			},
+			wantRet:     3,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test execute jobs with retry",
+			args: args{
+				concurrency: 10,
+				opts:        []OptionPool{WithRetry(3), WithError(nil), WithErrors(nil)}, // Error will be masked at runner
+			},
+			jobs: func(bwkp BWorkerKeyedPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				numJob, wantErrLen := 200, 50
+				for i := 0; i < numJob; i++ {
+					icp := i
+					bwkp.Do(fmt.Sprint(i%7), func() error {
+						mu.Lock()
+						defer mu.Unlock()
+						ret++
+						if icp < wantErrLen {
+							return errors.New("an error")
+						}
+						return nil
+					})
+				}
+				return &ret
+			},
+			wantRet:     (50 * (1 + 3)) + 150, // (wantErrLen*(1+numRetry)) + doSuccessLen
+			wantErr:     true,
+			wantErrsLen: 50,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				err  error
+				errs []error
+			)
+			for _, opt := range tt.args.opts {
+				switch o := opt.(type) {
+				case *withError:
+					o.e = &err
+				case *withErrors:
+					o.es = &errs
+				}
+			}
+			bwkp := NewKeyedPool(tt.args.concurrency, tt.args.opts...)
+			assert.False(t, bwkp.IsDead())
+			defer func() {
+				bwkp.Shutdown()
+				assert.True(t, bwkp.IsDead())
+			}()
+			if tt.jobs != nil {
+				gotNumExecuted := tt.jobs(bwkp)
+				bwkp.Wait()
+				assert.Equal(t, tt.wantRet, *gotNumExecuted)
+			}
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantErrsLen, len(errs))
+			for _, v := range errs {
+				assert.Error(t, v)
+			}
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package pool
 
 import (
+	"context"
 	"github.com/bearaujus/bworker/internal"
+	"log/slog"
 	"time"
 )
 
@@ -9,6 +11,21 @@ type OptionPool interface {
 	Apply(o *internal.OptionPool)
 }
 
+// WithName set the name of the worker pool. The name tells the pools of a process apart in the list returned by
+// bworker.Pools, and it is added as the pool attribute of every record when using WithLogger.
+func WithName(name string) OptionPool {
+	return &withName{name}
+}
+
+type withName struct{ name string }
+
+func (w *withName) Apply(o *internal.OptionPool) {
+	if w.name == "" {
+		return
+	}
+	o.Name = w.name
+}
+
 // WithJobPoolSize set the size of the job pool size. If you're not using this option, the default job pool size is 1.
 // [DEPRECATED] pool size will automatically adjust with num of concurrency.
 func WithJobPoolSize(n int) OptionPool {
@@ -42,7 +59,168 @@ func (w *withStartupStagger) Apply(o *internal.OptionPool) {
 	o.StartupStagger = w.d
 }
 
-// WithRetry set the number of times to retry a failed job.
+// WithPriorityAging set the worker pool to raise the priority of a queued job by 1 for every d it has been waiting.
+//
+// For example, if you set 1s aging, a job submitted with priority 0 that has been waiting for 3s will be executed
+// before a job submitted with priority 2 just now. Without this option, queued jobs never change their priority.
+func WithPriorityAging(d time.Duration) OptionPool {
+	return &withPriorityAging{d}
+}
+
+type withPriorityAging struct{ d time.Duration }
+
+func (w *withPriorityAging) Apply(o *internal.OptionPool) {
+	if w.d <= 0 {
+		return
+	}
+	o.PriorityAging = w.d
+}
+
+// WithClasses set the worker pool to schedule jobs submitted with DoClass by weighted fair queuing between classes.
+// The classes map a class name to its weight, a class with a non-positive weight is ignored.
+//
+// For example, if you set {"interactive": 3, "background": 1} and both classes have queued jobs, 3 interactive jobs
+// are started for every background job. When one of the classes has no queued job, the other class can use
+// all the workers.
+func WithClasses(classes map[string]int) OptionPool {
+	return &withClasses{classes}
+}
+
+type withClasses struct{ classes map[string]int }
+
+func (w *withClasses) Apply(o *internal.OptionPool) {
+	o.Classes = w.classes
+}
+
+// WithWeightCapacity set the total weight of jobs that can be executed at the same time, in addition to the
+// concurrency level. A job submitted with DoWeighted is started only when its weight fits into the remaining weight
+// capacity, and a job heavier than the weight capacity is started alone.
+//
+// For example, if you set 10 weight capacity, the pool can execute 10 jobs with weight 1, or 1 job with weight 8
+// and 2 jobs with weight 1 at the same time.
+func WithWeightCapacity(n int64) OptionPool {
+	return &withWeightCapacity{n}
+}
+
+type withWeightCapacity struct{ n int64 }
+
+func (w *withWeightCapacity) Apply(o *internal.OptionPool) {
+	if w.n <= 0 {
+		return
+	}
+	o.WeightCapacity = w.n
+}
+
+// WithJobTimeout set the maximum duration of every attempt of a job. It only takes effect for a job submitted with
+// DoHandle that reads its context: the job runs with a context deadline, and an attempt that returns an error after
+// the deadline fails with ErrJobTimeout, while an attempt that returns nil is succeeded. Jobs submitted with Do and
+// DoSimple do not see the deadline and are not affected. The worker waits for a timed out attempt to return before
+// retrying it or taking the next job, so a job that ignores its context keeps its worker until it returns.
+func WithJobTimeout(d time.Duration) OptionPool {
+	return &withJobTimeout{d}
+}
+
+type withJobTimeout struct{ d time.Duration }
+
+func (w *withJobTimeout) Apply(o *internal.OptionPool) {
+	if w.d <= 0 {
+		return
+	}
+	o.JobTimeout = w.d
+}
+
+// WithWatchdog set the worker pool to call the callback in a separate goroutine when a job has been running longer
+// than the threshold. The callback receives the label of the job, the index of its worker and the elapsed time, and
+// StuckJob.Stack can capture a goroutine stack dump while the job is still stuck. A job is reported at most once.
+func WithWatchdog(threshold time.Duration, callback func(job StuckJob)) OptionPool {
+	return &withWatchdog{threshold, callback}
+}
+
+type withWatchdog struct {
+	threshold time.Duration
+	callback  func(job StuckJob)
+}
+
+func (w *withWatchdog) Apply(o *internal.OptionPool) {
+	if w.threshold <= 0 || w.callback == nil {
+		return
+	}
+	o.Watchdog = w.threshold
+	o.OnStuck = w.callback
+}
+
+// WithTracer set a Tracer to be called when a job is submitted, started and completed, and around every attempt of
+// the job. The context of a job submitted with DoHandle and WithContext is passed to the Tracer, so a trace can be
+// continued by the worker. See the tracing package for an adapter creating a span per job and per attempt.
+func WithTracer(t Tracer) OptionPool {
+	return &withTracer{t}
+}
+
+type withTracer struct{ t Tracer }
+
+func (w *withTracer) Apply(o *internal.OptionPool) {
+	o.Tracer = w.t
+}
+
+// WithHooks set the Hooks to be called with a JobInfo when a job is submitted, started, retried, completed or dropped.
+// A nil hook is ignored. The hooks are called synchronously, so a slow hook delays the submitter or the worker. A hook
+// may call the pool, except Shutdown and Restart which wait for the job being submitted or executed.
+func WithHooks(h Hooks) OptionPool {
+	return &withHooks{h}
+}
+
+type withHooks struct{ h Hooks }
+
+func (w *withHooks) Apply(o *internal.OptionPool) {
+	o.Hooks = w.h
+}
+
+// WithLogger set a logger to emit structured records for the retries, failures, panics and drops of the jobs, with
+// the label and the attempt of the job as attributes. A retry is logged with the error of the previous attempt.
+// The phases of Shutdown are logged as well. If you're not using this option, the worker pool is silent.
+func WithLogger(l *slog.Logger) OptionPool {
+	return &withLogger{l}
+}
+
+type withLogger struct{ l *slog.Logger }
+
+func (w *withLogger) Apply(o *internal.OptionPool) {
+	o.Logger = w.l
+}
+
+// WithProfiling set the worker pool to label its worker goroutines with pprof labels, bworker.pool for the name set
+// with WithName and bworker.worker for the index of the worker, and to add the bworker.job label of a job submitted
+// with WithLabel while it is executed. Every job is also wrapped in a runtime/trace task and region named
+// bworker.job, so go tool pprof and go tool trace can attribute the cost to individual pools.
+func WithProfiling() OptionPool {
+	return &withProfiling{}
+}
+
+type withProfiling struct{}
+
+func (w *withProfiling) Apply(o *internal.OptionPool) {
+	o.Profiling = true
+}
+
+// WithExpvar set the worker pool to publish its Stats under the name with the expvar package, so they are served as
+// JSON on /debug/vars alongside memstats. The last Stats are still published after Shutdown, and a pool created or
+// restarted later with the same name replaces them. If the name is already published by another package or by another
+// pool that is not shut down, the Stats are not published and a warning is logged with WithLogger.
+func WithExpvar(name string) OptionPool {
+	return &withExpvar{name}
+}
+
+type withExpvar struct{ name string }
+
+func (w *withExpvar) Apply(o *internal.OptionPool) {
+	if w.name == "" {
+		return
+	}
+	o.Expvar = w.name
+}
+
+// WithRetry set the number of times to retry a failed job. A job that panics is recovered and fails with an error
+// wrapping ErrJobPanic.
 func WithRetry(n int) OptionPool {
 	return &withRetry{n}
 }
@@ -56,6 +234,20 @@ func (w *withRetry) Apply(o *internal.OptionPool) {
 	o.Retry = w.n
 }
 
+// WithRetryIf set a function to decide whether a failed job is retried by its error. If you're not using this option,
+// every error is retried. For example, you can retry timeouts only with:
+//
+//	WithRetryIf(func(err error) bool { return errors.Is(err, ErrJobTimeout) })
+func WithRetryIf(f func(err error) bool) OptionPool {
+	return &withRetryIf{f}
+}
+
+type withRetryIf struct{ f func(err error) bool }
+
+func (w *withRetryIf) Apply(o *internal.OptionPool) {
+	o.RetryIf = w.f
+}
+
 // WithError set a pointer to an error variable that will be populated if any job fails.
 func WithError(e *error) OptionPool {
 	return &withError{e}
@@ -77,3 +269,55 @@ type withErrors struct{ es *[]error }
 func (w *withErrors) Apply(o *internal.OptionPool) {
 	o.Errs = w.es
 }
+
+type OptionJob interface {
+	Apply(o *internal.OptionJob)
+}
+
+// WithTimeout set the maximum duration of every attempt of a job submitted with DoHandle, overriding WithJobTimeout.
+func WithTimeout(d time.Duration) OptionJob {
+	return &withTimeout{d}
+}
+
+type withTimeout struct{ d time.Duration }
+
+func (w *withTimeout) Apply(o *internal.OptionJob) {
+	if w.d <= 0 {
+		return
+	}
+	o.Timeout = w.d
+}
+
+// WithLabel set the label of a job submitted with DoHandle, which is reported by WithWatchdog.
+func WithLabel(label string) OptionJob {
+	return &withLabel{label}
+}
+
+type withLabel struct{ label string }
+
+func (w *withLabel) Apply(o *internal.OptionJob) {
+	o.Label = w.label
+}
+
+// WithContext set the context of a job submitted with DoHandle. Its values, such as a trace span, are passed to the
+// Tracer and visible to the job, while its cancellation is not propagated to the job.
+func WithContext(ctx context.Context) OptionJob {
+	return &withContext{ctx}
+}
+
+type withContext struct{ ctx context.Context }
+
+func (w *withContext) Apply(o *internal.OptionJob) {
+	o.Context = w.ctx
+}
+
+func newOptionJob(opts []OptionJob) *internal.OptionJob {
+	o := &internal.OptionJob{}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt.Apply(o)
+	}
+	return o
+}
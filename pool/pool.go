@@ -1,55 +1,274 @@
 package pool
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/bearaujus/bworker/internal"
+	"log/slog"
+	"runtime/pprof"
+	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+var (
+	// ErrPoolClosed is reported when a job is submitted to a BWorkerPool that IsDead. The job is dropped.
+	ErrPoolClosed = errors.New("worker pool is closed")
+
+	// ErrNilJob is returned by Submit and SubmitSimple when the job is nil.
+	ErrNilJob = errors.New("job is nil")
+)
+
+// State is the lifecycle state of a BWorkerPool.
+type State int
+
+const (
+	// StateCreated indicates the pool is being created or restarted, and its workers are not started yet.
+	StateCreated State = iota
+	// StateRunning indicates the workers are executing the submitted jobs.
+	StateRunning
+	// StatePaused indicates the workers stopped pulling new jobs from the queue until Resume.
+	StatePaused
+	// StateDraining indicates the pool is shutting down and completing its remaining jobs. New jobs are dropped.
+	StateDraining
+	// StateStopped indicates the pool is shut down. New jobs are dropped until Restart.
+	StateStopped
+)
+
+func (s State) String() string {
+	switch s {
+	case StateCreated:
+		return "created"
+	case StateRunning:
+		return "running"
+	case StatePaused:
+		return "paused"
+	case StateDraining:
+		return "draining"
+	case StateStopped:
+		return "stopped"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// ErrJobTimeout is the error of a job attempt that returns an error after the deadline of WithJobTimeout or WithTimeout.
+var ErrJobTimeout = internal.ErrJobTimeout
+
+// ErrJobPanic is wrapped by the error of a job attempt that panicked. The panic is recovered and the attempt is
+// retried like a failed one.
+var ErrJobPanic = internal.ErrJobPanic
+
+// Tracer is called around the execution of a job when using WithTracer.
+type Tracer = internal.Tracer
+
+// Hooks are called on the lifecycle events of every job when using WithHooks.
+type Hooks = internal.Hooks
+
+// JobInfo describe a job passed to the Hooks.
+type JobInfo = internal.JobInfo
+
+// Stats is a snapshot of the runtime statistics of a BWorkerPool returned by Stats.
+type Stats = internal.Stats
+
+// Histogram is a snapshot of a distribution of durations reported by Stats, with its p50, p90 and p99.
+type Histogram = internal.Histogram
+
+// StuckJob describe a job reported by WithWatchdog.
+type StuckJob = internal.StuckJob
+
+// JobHandle is returned by DoHandle to cancel a job and to follow its JobStatus.
+type JobHandle = internal.JobHandle
+
+// JobStatus is the state of a job submitted with DoHandle.
+type JobStatus = internal.JobStatus
+
+const (
+	JobQueued    = internal.JobQueued
+	JobRunning   = internal.JobRunning
+	JobRetrying  = internal.JobRetrying
+	JobSucceeded = internal.JobSucceeded
+	JobFailed    = internal.JobFailed
+	JobCancelled = internal.JobCancelled
+)
+
 type BWorkerPool interface {
-	// Do submit a job to be executed by a worker. If IsDead this function will perform no-op.
+	// Do submit a job to be executed by a worker. If IsDead the job is dropped and ErrPoolClosed is reported to
+	// WithError and WithErrors.
 	// This function may block the thread (see pool/pool_test.go for more details).
 	//
 	// To avoid thread blocking, you can adjust the inputted job like adding context with deadline to it.
 	// Also, you can consider using WithJobPoolSize.
 	Do(job func() error)
 
-	// DoSimple submit a job to be executed by a worker without an error. If IsDead this function will perform no-op.
+	// DoSimple submit a job to be executed by a worker without an error. If IsDead the job is dropped and
+	// ErrPoolClosed is reported to WithError and WithErrors.
 	// This function may block the thread (see pool/pool_test.go for more details).
 	//
 	// To avoid thread blocking, you can adjust the inputted job like adding context with deadline to it.
 	// Also, you can consider using WithJobPoolSize.
 	DoSimple(job func())
 
+	// Submit submit a job to be executed by a worker like Do, and return ErrPoolClosed if IsDead or the pool is shut
+	// down concurrently, so the job is never dropped silently. The errors returned by Submit are not reported to
+	// WithError and WithErrors. It returns ErrNilJob if the job is nil.
+	// This function may block the thread (see pool/pool_test.go for more details).
+	Submit(job func() error) error
+
+	// SubmitSimple submit a job to be executed by a worker without an error like DoSimple, and return ErrPoolClosed if
+	// IsDead or the pool is shut down concurrently. It returns ErrNilJob if the job is nil.
+	// This function may block the thread (see pool/pool_test.go for more details).
+	SubmitSimple(job func()) error
+
+	// DoHandle submit a job to be executed by a worker with a context, and return a JobHandle to cancel the job and
+	// to follow its JobStatus. A cancelled job that is still queued is skipped by the worker, and a running job sees
+	// its context cancelled. If the job is nil the returned JobHandle is already cancelled, and if IsDead it is already
+	// cancelled with ErrPoolClosed.
+	// This function may block the thread (see pool/pool_test.go for more details).
+	DoHandle(job func(ctx context.Context) error, opts ...OptionJob) JobHandle
+
+	// DoPriority submit a job with a priority to be executed by a worker. If IsDead the job is dropped and
+	// ErrPoolClosed is reported to WithError and WithErrors. Queued jobs with a higher priority are executed first, and
+	// jobs with the same priority are executed in submission order. Do and DoSimple submit jobs with priority 0.
+	// This function may block the thread (see pool/pool_test.go for more details).
+	//
+	// To prevent low priority jobs from starving, you can consider using WithPriorityAging.
+	DoPriority(priority int, job func() error)
+
+	// DoClass submit a job of a class registered with WithClasses to be executed by a worker. If IsDead the job is
+	// dropped and ErrPoolClosed is reported to WithError and WithErrors. Jobs of an unknown class, and jobs submitted
+	// with Do, DoSimple and DoPriority, belong to the default class with weight 1.
+	// This function may block the thread (see pool/pool_test.go for more details).
+	DoClass(class string, job func() error)
+
+	// DoWeighted submit a job with a weight to be executed by a worker. If IsDead the job is dropped and ErrPoolClosed
+	// is reported to WithError and WithErrors. When using WithWeightCapacity, the job is started only when its weight
+	// fits into the remaining weight capacity of the pool. Jobs submitted with the other functions have a weight of 1.
+	// This function may block the thread (see pool/pool_test.go for more details).
+	DoWeighted(weight int64, job func() error)
+
+	// DoAfter submit a job to be executed by a worker after the duration d. If IsDead the job is dropped and
+	// ErrPoolClosed is reported to WithError and WithErrors. The job is counted by Wait as soon as it is submitted, and
+	// it is dropped if Shutdown is performed before the duration d elapses.
+	DoAfter(d time.Duration, job func() error)
+
+	// DoAt submit a job to be executed by a worker at the time t. If IsDead the job is dropped and ErrPoolClosed is
+	// reported to WithError and WithErrors. The job is counted by Wait as soon as it is submitted, and it is dropped if
+	// Shutdown is performed before the time t.
+	DoAt(t time.Time, job func() error)
+
 	// Wait wait for all jobPool to be completed. If IsDead this function will perform no-op.
+	// While the pool IsPaused, this function blocks until Resume is performed.
 	Wait()
 
-	// Shutdown shut down the worker pool. After performing this operation, Do and DoSimple will perform no-op.
+	// Pause stop the workers from pulling new jobs from the queue, while the jobs being executed are completed.
+	// Submitted jobs are still queued and may block the thread the same way as Do once the queue is full.
 	// If IsDead this function will perform no-op.
+	Pause()
+
+	// Resume let the workers pull jobs from the queue again after Pause. If IsDead this function will perform no-op.
+	Resume()
+
+	// IsPaused indicates the BWorkerPool is paused or not.
+	IsPaused() bool
+
+	// Shutdown shut down the worker pool and its sub-pools. A paused pool is resumed to complete its queued jobs.
+	// Jobs submitted with DoAfter and DoAt that are not due yet will be dropped. After performing this operation,
+	// submitted jobs are dropped until Restart. If IsDead this function will perform no-op.
 	Shutdown()
 
 	// IsDead indicates the BWorkerPool is already shut down or not.
 	IsDead() bool
 
+	// State return the current lifecycle State of the BWorkerPool.
+	State() State
+
+	// Name return the name of the BWorkerPool set with WithName, or empty if it is not set.
+	Name() string
+
+	// Resize change the concurrency level of the BWorkerPool. New workers are started right away, while the workers
+	// above the new concurrency level stop once their current job is completed, even if the pool IsPaused. If IsDead
+	// the new concurrency level is applied on Restart. A non-positive concurrency level is ignored.
+	Resize(concurrency int)
+
+	// Stats return a snapshot of the runtime statistics of the BWorkerPool, such as the number of queued and running
+	// jobs, the number of idle workers and the Histogram of the queue wait and execution time of the jobs. The
+	// counters are kept across Restart. The jobs of a Group are counted once with their own outcome, retries and
+	// errors, and the jobs of a sub-pool are only counted by the sub-pool.
+	Stats() Stats
+
+	// Restart start the workers again with the same concurrency level and OptionPool(s) after Shutdown. If the pool
+	// is not shut down yet, it is shut down first. The errors set by WithError and WithErrors are kept, and the
+	// sub-pools shut down with the pool are not restarted. If this pool is a sub-pool whose parent IsDead, this
+	// function will perform no-op.
+	Restart()
+
 	// ClearErr reset the error variable when you are using WithErrors.
 	ClearErr()
 
 	// ClearErrs reset the slice of error variables when you are using WithErrors.
 	ClearErrs()
+
+	// Group create a BWorkerGroup to submit jobs that can be waited for separately from the other jobs of the pool.
+	// The jobs of the group are executed by the workers of the pool with its retry option, and their errors are
+	// reported by the group instead of WithError and WithErrors. They are reported to the Stats, WithHooks and
+	// WithTracer of the pool like the other jobs.
+	Group() BWorkerGroup
+
+	// Sub create a sub-pool with its own OptionPool(s) whose jobs are executed by the workers of this pool. The
+	// sub-pool executes at most maxConcurrency jobs at the same time, and they count toward the concurrency level of
+	// this pool, so nested sub-pools can never exceed the concurrency level of the root pool. The watchdog, the running
+	// jobs and the profiling labels of the sub-pool only count a job once it is executed by a worker of this pool.
+	//
+	// Shutdown of this pool shuts down its sub-pools first. Wait of this pool does not wait for the jobs of its
+	// sub-pools. If IsDead the returned sub-pool is already shut down.
+	Sub(maxConcurrency int, opts ...OptionPool) BWorkerPool
 }
 
 type bWorkerPool struct {
+	option       *internal.OptionPool
 	ctxManager   *internal.CtxManager
 	jobManager   *internal.JobManager
-	jobPool      chan internal.PendingJob
+	scheduler    *internal.Scheduler
+	delayer      *internal.Delayer
+	watchdog     *internal.Watchdog
 	errorManager *internal.ErrorManager
 	wgWorker     *sync.WaitGroup
+	// busyWorkers is the number of workers executing a job, updated atomically.
+	busyWorkers int32
+	// lifecycleMu serialize Shutdown, Restart and Resize, so a State transition is completed before the next one
+	// starts.
+	lifecycleMu *sync.Mutex
+	// parent is the pool executing the jobs of a sub-pool, or nil for a pool created with NewBWorkerPool
+	parent *bWorkerPool
+	mu     *sync.Mutex
+	state  State
+	subs   map[*bWorkerPool]struct{}
+	// concurrency is the number of workers, and nextWorker is the index of the next worker created by Resize.
+	concurrency int
+	nextWorker  int
+	// running holds an *atomic.Pointer[runningJob] by the index of every worker, so a worker updates its own job
+	// without a lock. The pointer is nil while the worker is idle.
+	running *sync.Map
+	// expvar indicates the pool holds the name set with WithExpvar until it is shut down, guarded by lifecycleMu.
+	expvar bool
+}
+
+type runningJob struct {
+	label string
+	start time.Time
 }
 
 // NewBWorkerPool create a new BWorkerPool with OptionPool(s) and specified concurrency level.
 //
 // Please use BWorkerPool.Shutdown() to avoid memory leak from the unclosed channel(s).
 func NewBWorkerPool(concurrency int, opts ...OptionPool) BWorkerPool {
+	return newBWorkerPool(nil, concurrency, opts...)
+}
+
+func newBWorkerPool(parent *bWorkerPool, concurrency int, opts ...OptionPool) *bWorkerPool {
 	if concurrency <= 0 {
 		concurrency = 1
 	}
@@ -60,56 +279,317 @@ func NewBWorkerPool(concurrency int, opts ...OptionPool) BWorkerPool {
 		}
 		opt.Apply(o)
 	}
+	if o.Name != "" && o.Logger != nil {
+		o.Logger = o.Logger.With(slog.String("pool", o.Name))
+	}
 	em := internal.NewErrorManager(o.Err, o.Errs)
 	bwp := &bWorkerPool{
+		option:     o,
 		ctxManager: internal.NewCtxManager(),
-		jobManager: internal.NewJobManager(o.Retry, em),
+		jobManager: internal.NewJobManager(o.OptionJobManager, em),
 		// If o.JobPoolSize = 0. It's basically the same with o.JobPoolSize = 1
-		jobPool:      make(chan internal.PendingJob, o.JobPoolSize),
+		scheduler:    internal.NewScheduler(o.JobPoolSize, o.PriorityAging, o.Classes, o.WeightCapacity),
+		watchdog:     internal.NewWatchdog(o.Watchdog, o.OnStuck),
 		errorManager: em,
 		wgWorker:     &sync.WaitGroup{},
+		lifecycleMu:  &sync.Mutex{},
+		parent:       parent,
+		mu:           &sync.Mutex{},
+		state:        StateCreated,
+		subs:         make(map[*bWorkerPool]struct{}),
+		concurrency:  concurrency,
+		running:      &sync.Map{},
 	}
+	bwp.delayer = internal.NewDelayer(func(pendingJob internal.PendingJob) {
+		bwp.push(pendingJob, "", internal.DefaultClass, 0, 1)
+	})
+	bwp.start()
+	return bwp
+}
+
+// start create the workers of the pool and add the pool to the registry listed by bworker.Pools.
+func (bwp *bWorkerPool) start() {
+	o := bwp.option
+	bwp.mu.Lock()
+	concurrency := bwp.concurrency
+	bwp.nextWorker = concurrency
+	bwp.mu.Unlock()
 	var startupDelay time.Duration
 	if concurrency != 1 && o.StartupStagger != 0 {
 		startupDelay = o.StartupStagger / time.Duration(concurrency-1)
 	}
+	bwp.setState(StateRunning)
+	internal.Register(bwp)
+	if o.Expvar != "" {
+		if bwp.expvar = internal.PublishExpvar(o.Expvar, func() interface{} { return bwp.Stats() }); !bwp.expvar {
+			internal.Log(o.Logger, slog.LevelWarn, "pool stats not published, the expvar name is already used", slog.String("expvar", o.Expvar))
+		}
+	}
+	ctx := bwp.ctxManager.Ctx()
 	bwp.wgWorker.Add(concurrency)
 	go func() {
 		for i := 0; i < concurrency; i++ {
+			worker := i
 			// the first worker will always start, before using startupDelay when using WithStartupStagger
 			if i != 0 && o.StartupStagger != 0 {
 				select {
 				case <-time.Tick(startupDelay):
-				case <-bwp.ctxManager.Ctx().Done():
+				case <-ctx.Done():
 				}
 			}
 			// Create a worker
-			go func() {
-				defer bwp.wgWorker.Done()
-				// Keep pulling jobs until bw.jobPool is closed
-				for job := range bwp.jobPool {
-					job()
-				}
-			}()
+			go bwp.work(worker)
 		}
 	}()
-	return bwp
+}
+
+// work pull jobs and execute them on the worker until the scheduler is closed or the worker is retired by Resize.
+func (bwp *bWorkerPool) work(worker int) {
+	defer bwp.wgWorker.Done()
+	o := bwp.option
+	var pctx context.Context
+	if o.Profiling {
+		pctx = internal.ProfileLabels(context.Background(), "bworker.pool", o.Name, "bworker.worker", strconv.Itoa(worker))
+		pprof.SetGoroutineLabels(pctx)
+	}
+	current := &atomic.Pointer[runningJob]{}
+	bwp.running.Store(worker, current)
+	defer bwp.running.Delete(worker)
+	// Keep pulling jobs until bwp.scheduler is closed
+	for {
+		job, label, ok := bwp.scheduler.PopLabeled()
+		if !ok {
+			return
+		}
+		atomic.AddInt32(&bwp.busyWorkers, 1)
+		bwp.execute(func() {
+			current.Store(&runningJob{label: label, start: time.Now()})
+			defer current.Store(nil)
+			bwp.watchdog.Watch(worker, label, func() {
+				if pctx == nil {
+					job()
+					return
+				}
+				if bwp.parent != nil {
+					// The job runs on a worker of the parent, whose labels are restored by its own Profile, if any
+					defer pprof.SetGoroutineLabels(context.Background())
+				}
+				internal.Profile(pctx, label, job)
+			})
+		}, label)
+		atomic.AddInt32(&bwp.busyWorkers, -1)
+	}
+}
+
+func (bwp *bWorkerPool) Resize(concurrency int) {
+	if concurrency <= 0 {
+		return
+	}
+	bwp.lifecycleMu.Lock()
+	defer bwp.lifecycleMu.Unlock()
+	bwp.mu.Lock()
+	prev, first := bwp.concurrency, bwp.nextWorker
+	bwp.concurrency = concurrency
+	if concurrency > prev {
+		bwp.nextWorker += concurrency - prev
+	}
+	bwp.mu.Unlock()
+	bwp.scheduler.SetSize(concurrency)
+	internal.Log(bwp.option.Logger, slog.LevelInfo, "pool resized", slog.Int("from", prev), slog.Int("to", concurrency))
+	if bwp.ctxManager.IsDead() {
+		// The workers are created with the new concurrency level on Restart
+		return
+	}
+	if concurrency < prev {
+		bwp.scheduler.Retire(prev - concurrency)
+		return
+	}
+	bwp.wgWorker.Add(concurrency - prev)
+	for i := 0; i < concurrency-prev; i++ {
+		go bwp.work(first + i)
+	}
+}
+
+func (bwp *bWorkerPool) setState(state State) {
+	bwp.mu.Lock()
+	defer bwp.mu.Unlock()
+	bwp.state = state
+}
+
+// register add a sub-pool to its parent, so it is shut down along with the parent. It returns false if the parent
+// IsDead.
+func (bwp *bWorkerPool) register() bool {
+	if bwp.parent == nil {
+		return true
+	}
+	bwp.parent.mu.Lock()
+	defer bwp.parent.mu.Unlock()
+	if bwp.parent.ctxManager.IsDead() {
+		return false
+	}
+	bwp.parent.subs[bwp] = struct{}{}
+	return true
+}
+
+// execute run job on the current worker. The worker of a sub-pool hands the job over to its parent instead, and
+// waits until it is completed so the sub-pool does not exceed its own concurrency level. The running job, the
+// watchdog and the profiling labels of the sub-pool are started by job, so the time the job waits in the queue of the
+// parent is not counted as running.
+func (bwp *bWorkerPool) execute(job internal.PendingJob, label string) {
+	if bwp.parent == nil {
+		job()
+		return
+	}
+	done := make(chan struct{})
+	if !bwp.parent.scheduler.PushLabeled(func() {
+		defer close(done)
+		job()
+	}, label, internal.DefaultClass, 0, 1) {
+		// The parent shuts down its sub-pools before closing its scheduler, this only guards the remaining jobs
+		job()
+		return
+	}
+	<-done
+}
+
+func (bwp *bWorkerPool) Sub(maxConcurrency int, opts ...OptionPool) BWorkerPool {
+	sub := newBWorkerPool(bwp, maxConcurrency, opts...)
+	if !sub.register() {
+		sub.Shutdown()
+	}
+	return sub
 }
 
 func (bwp *bWorkerPool) Do(job func() error) {
-	if bwp.ctxManager.IsDead() || job == nil {
+	if job == nil {
 		return
 	}
-	pendingJob := bwp.jobManager.NewJob(job)
-	bwp.jobPool <- pendingJob
+	bwp.errorManager.SetIfNotNil(bwp.Submit(job))
 }
 
 func (bwp *bWorkerPool) DoSimple(job func()) {
-	if bwp.ctxManager.IsDead() || job == nil {
+	if job == nil {
+		return
+	}
+	bwp.errorManager.SetIfNotNil(bwp.SubmitSimple(job))
+}
+
+func (bwp *bWorkerPool) Submit(job func() error) error {
+	if job == nil {
+		return ErrNilJob
+	}
+	return bwp.submit(func() internal.PendingJob {
+		return bwp.jobManager.NewJob(job)
+	}, "", internal.DefaultClass, 0, 1)
+}
+
+func (bwp *bWorkerPool) SubmitSimple(job func()) error {
+	if job == nil {
+		return ErrNilJob
+	}
+	return bwp.submit(func() internal.PendingJob {
+		return bwp.jobManager.NewJobSimple(job)
+	}, "", internal.DefaultClass, 0, 1)
+}
+
+func (bwp *bWorkerPool) DoHandle(job func(ctx context.Context) error, opts ...OptionJob) JobHandle {
+	if job == nil {
+		return internal.NewDroppedJobHandle(nil)
+	}
+	o := newOptionJob(opts)
+	if !bwp.ctxManager.Enter() {
+		return internal.NewDroppedJobHandle(ErrPoolClosed)
+	}
+	defer bwp.ctxManager.Leave()
+	pendingJob, handle := bwp.jobManager.NewJobHandle(job, o)
+	if !bwp.scheduler.PushLabeled(pendingJob, o.Label, internal.DefaultClass, 0, 1) {
+		bwp.jobManager.Drop(handle, ErrPoolClosed)
+	}
+	return handle
+}
+
+func (bwp *bWorkerPool) DoPriority(priority int, job func() error) {
+	if job == nil {
 		return
 	}
-	pendingJob := bwp.jobManager.NewJobSimple(job)
-	bwp.jobPool <- pendingJob
+	bwp.errorManager.SetIfNotNil(bwp.submit(func() internal.PendingJob {
+		return bwp.jobManager.NewJob(job)
+	}, "", internal.DefaultClass, priority, 1))
+}
+
+func (bwp *bWorkerPool) DoClass(class string, job func() error) {
+	if job == nil {
+		return
+	}
+	bwp.errorManager.SetIfNotNil(bwp.submit(func() internal.PendingJob {
+		return bwp.jobManager.NewJob(job)
+	}, "", class, 0, 1))
+}
+
+func (bwp *bWorkerPool) DoWeighted(weight int64, job func() error) {
+	if job == nil {
+		return
+	}
+	bwp.errorManager.SetIfNotNil(bwp.submit(func() internal.PendingJob {
+		return bwp.jobManager.NewJob(job)
+	}, "", internal.DefaultClass, 0, weight))
+}
+
+func (bwp *bWorkerPool) DoAfter(d time.Duration, job func() error) {
+	bwp.DoAt(time.Now().Add(d), job)
+}
+
+func (bwp *bWorkerPool) DoAt(t time.Time, job func() error) {
+	if job == nil {
+		return
+	}
+	if !bwp.ctxManager.Enter() {
+		bwp.errorManager.SetIfNotNil(ErrPoolClosed)
+		return
+	}
+	defer bwp.ctxManager.Leave()
+	if !bwp.delayer.Add(t, bwp.jobManager.NewJobAt(t, job)) {
+		bwp.jobManager.Done(ErrPoolClosed)
+		bwp.errorManager.SetIfNotNil(ErrPoolClosed)
+	}
+}
+
+// DoChain implements internal.ChainDoer for the schedule and dag packages. The job is queued without waiting for room
+// in the job pool, and ErrPoolClosed is not reported to WithError and WithErrors.
+func (bwp *bWorkerPool) DoChain(c internal.Chain) bool {
+	if !bwp.ctxManager.Enter() {
+		return false
+	}
+	defer bwp.ctxManager.Leave()
+	if !bwp.scheduler.PushNoWait(bwp.jobManager.NewJobChain(c.Job, c.Next), "", internal.DefaultClass, 0, 1) {
+		bwp.jobManager.Done(ErrPoolClosed)
+		return false
+	}
+	return true
+}
+
+// push queue pendingJob to the scheduler. The job is dropped if the pool is shut down before it is queued.
+func (bwp *bWorkerPool) push(pendingJob internal.PendingJob, label string, class string, priority int, weight int64) bool {
+	if !bwp.scheduler.PushLabeled(pendingJob, label, class, priority, weight) {
+		bwp.jobManager.Done(ErrPoolClosed)
+		return false
+	}
+	return true
+}
+
+// submit create a job with newJob and queue it like push. The job is only created while the pool is alive, and
+// Shutdown waits for the submission to leave before it waits for the jobs, so the job is always counted. newJob is not
+// called under the lock of the pool, so the Hooks and the Tracer may call the pool. It returns ErrPoolClosed if the
+// job is dropped.
+func (bwp *bWorkerPool) submit(newJob func() internal.PendingJob, label string, class string, priority int, weight int64) error {
+	if !bwp.ctxManager.Enter() {
+		return ErrPoolClosed
+	}
+	defer bwp.ctxManager.Leave()
+	if !bwp.push(newJob(), label, class, priority, weight) {
+		return ErrPoolClosed
+	}
+	return nil
 }
 
 func (bwp *bWorkerPool) Wait() {
@@ -120,16 +600,150 @@ func (bwp *bWorkerPool) Wait() {
 	bwp.jobManager.Wait()
 }
 
+func (bwp *bWorkerPool) Pause() {
+	if bwp.ctxManager.IsDead() {
+		return
+	}
+	bwp.scheduler.Pause()
+}
+
+func (bwp *bWorkerPool) Resume() {
+	if bwp.ctxManager.IsDead() {
+		return
+	}
+	bwp.scheduler.Resume()
+}
+
+func (bwp *bWorkerPool) IsPaused() bool {
+	return bwp.scheduler.Paused()
+}
+
 func (bwp *bWorkerPool) Shutdown() {
+	bwp.lifecycleMu.Lock()
+	defer bwp.lifecycleMu.Unlock()
+	bwp.shutdown()
+}
+
+func (bwp *bWorkerPool) shutdown() {
 	if !bwp.ctxManager.Cancel() {
 		return
 	}
+	start := time.Now()
+	stats := bwp.jobManager.Stats()
+	internal.Log(bwp.option.Logger, slog.LevelInfo, "pool draining",
+		slog.Int64("queued", stats.Queued), slog.Int64("running", stats.Running))
+	bwp.setState(StateDraining)
+	// Resume the workers to complete the queued jobs
+	bwp.scheduler.Resume()
+	// Wait until the jobs being submitted are queued
+	bwp.ctxManager.WaitLeft()
+	// Shut down all sub-pools while the workers can still execute their jobs
+	bwp.mu.Lock()
+	subs := make([]*bWorkerPool, 0, len(bwp.subs))
+	for sub := range bwp.subs {
+		subs = append(subs, sub)
+	}
+	bwp.mu.Unlock()
+	for _, sub := range subs {
+		sub.Shutdown()
+	}
+	// Drop all delayed jobs that are not due yet
+	for range bwp.delayer.Stop() {
+		bwp.jobManager.Done(ErrPoolClosed)
+	}
 	// Wait until all jobs executed
 	bwp.jobManager.Wait()
+	internal.Log(bwp.option.Logger, slog.LevelInfo, "pool jobs drained")
 	// Shut down all active workers
-	close(bwp.jobPool)
+	bwp.scheduler.Close()
 	// Wait until all workers are dead
 	bwp.wgWorker.Wait()
+	internal.Log(bwp.option.Logger, slog.LevelInfo, "pool workers stopped")
+	if bwp.parent != nil {
+		bwp.parent.mu.Lock()
+		delete(bwp.parent.subs, bwp)
+		bwp.parent.mu.Unlock()
+	}
+	bwp.setState(StateStopped)
+	internal.Unregister(bwp)
+	if bwp.expvar {
+		internal.ReleaseExpvar(bwp.option.Expvar, bwp.Stats())
+		bwp.expvar = false
+	}
+	internal.Log(bwp.option.Logger, slog.LevelInfo, "pool stopped", slog.Duration("elapsed", time.Since(start)))
+}
+
+func (bwp *bWorkerPool) Restart() {
+	bwp.lifecycleMu.Lock()
+	defer bwp.lifecycleMu.Unlock()
+	if bwp.parent != nil && bwp.parent.ctxManager.IsDead() {
+		return
+	}
+	bwp.shutdown()
+	bwp.setState(StateCreated)
+	bwp.scheduler.Reopen()
+	bwp.delayer.Reset()
+	// Accept new jobs once the scheduler and the delayer are ready
+	bwp.ctxManager.Renew()
+	bwp.start()
+	if !bwp.register() {
+		// The parent is shut down in the meantime
+		bwp.shutdown()
+	}
+}
+
+func (bwp *bWorkerPool) State() State {
+	bwp.mu.Lock()
+	state := bwp.state
+	bwp.mu.Unlock()
+	if state == StateRunning && bwp.scheduler.Paused() {
+		return StatePaused
+	}
+	return state
+}
+
+func (bwp *bWorkerPool) Stats() Stats {
+	stats := bwp.jobManager.Stats()
+	if bwp.State() != StateStopped {
+		bwp.mu.Lock()
+		concurrency := bwp.concurrency
+		bwp.mu.Unlock()
+		// A worker retired by Resize is still counted as busy until its job is completed
+		if idle := int64(concurrency) - int64(atomic.LoadInt32(&bwp.busyWorkers)); idle > 0 {
+			stats.IdleWorkers = idle
+		}
+	}
+	return stats
+}
+
+func (bwp *bWorkerPool) Name() string {
+	return bwp.option.Name
+}
+
+// Info return the snapshot of the pool listed by bworker.Pools.
+func (bwp *bWorkerPool) Info() internal.PoolInfo {
+	bwp.mu.Lock()
+	concurrency := bwp.concurrency
+	bwp.mu.Unlock()
+	now := time.Now()
+	var running []internal.RunningJob
+	bwp.running.Range(func(worker, current interface{}) bool {
+		if rj := current.(*atomic.Pointer[runningJob]).Load(); rj != nil {
+			running = append(running, internal.RunningJob{Label: rj.label, Worker: worker.(int), Elapsed: now.Sub(rj.start)})
+		}
+		return true
+	})
+	sort.Slice(running, func(i, j int) bool {
+		return running[i].Worker < running[j].Worker
+	})
+	return internal.PoolInfo{
+		Name:        bwp.option.Name,
+		State:       bwp.State().String(),
+		Concurrency: concurrency,
+		Stats:       bwp.Stats(),
+		Running:     running,
+		Errors:      bwp.jobManager.RecentErrors(),
+	}
 }
 
 func (bwp *bWorkerPool) IsDead() bool {
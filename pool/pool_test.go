@@ -1,14 +1,38 @@
 package pool
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"expvar"
 	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"runtime/pprof"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
 func TestWorkerPool(t *testing.T) {
+	var (
+		stuckMu = &sync.Mutex{}
+		stuck   []StuckJob
+		hookMu  = &sync.Mutex{}
+		hooked  = make(map[string][]JobInfo)
+		logs    = &bytes.Buffer{}
+		// submitHook is set by a test before submitting a job
+		submitHook func(info JobInfo)
+	)
+	hook := func(event string) func(info JobInfo) {
+		return func(info JobInfo) {
+			hookMu.Lock()
+			defer hookMu.Unlock()
+			hooked[event] = append(hooked[event], info)
+		}
+	}
 	type args struct {
 		concurrency int
 		opts        []OptionPool
@@ -25,7 +49,7 @@ func TestWorkerPool(t *testing.T) {
 			name: "test use default value",
 			args: args{
 				concurrency: -1,
-				opts:        []OptionPool{WithJobPoolSize(-1), WithStartupStagger(-1), WithRetry(-1), nil},
+				opts:        []OptionPool{WithJobPoolSize(-1), WithStartupStagger(-1), WithPriorityAging(-1), WithWeightCapacity(-1), WithWatchdog(-1, nil), WithRetry(-1), WithLogger(nil), WithName(""), WithExpvar(""), nil},
 			},
 			jobs:        nil,
 			wantRet:     0,
@@ -43,6 +67,12 @@ func TestWorkerPool(t *testing.T) {
 
 				bwp.Do(nil)
 				bwp.DoSimple(nil)
+				bwp.DoPriority(0, nil)
+				bwp.DoClass("", nil)
+				bwp.DoWeighted(1, nil)
+				bwp.DoAfter(0, nil)
+				bwp.DoAt(time.Now(), nil)
+				assert.Equal(t, JobCancelled, bwp.DoHandle(nil).Status())
 				return &ret
 			},
 			wantRet:     0,
@@ -71,12 +101,181 @@ func TestWorkerPool(t *testing.T) {
 					defer mu.Unlock()
 					ret++
 				})
+				handle := bwp.DoHandle(func(ctx context.Context) error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return nil
+				})
+				<-handle.Done()
+				assert.Equal(t, JobCancelled, handle.Status())
 				return &ret
 			},
 			wantRet:     0,
 			wantErr:     false,
 			wantErrsLen: 0,
 		},
+		{
+			name: "test report closed pool",
+			args: args{
+				concurrency: 2,
+				opts:        []OptionPool{WithError(nil), WithErrors(nil)},
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				job := func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return nil
+				}
+				bwp.Shutdown()
+				bwp.Do(job)
+				bwp.DoSimple(func() {})
+				bwp.DoPriority(0, job)
+				bwp.DoClass("", job)
+				bwp.DoWeighted(1, job)
+				bwp.DoAfter(0, job)
+				bwp.DoAt(time.Now(), job)
+				// The dropped job is reported by its JobHandle instead
+				handle := bwp.DoHandle(func(ctx context.Context) error { return job() })
+				assert.Equal(t, JobCancelled, handle.Status())
+				assert.ErrorIs(t, handle.Err(), ErrPoolClosed)
+				return &ret
+			},
+			wantRet:     0,
+			wantErr:     true,
+			wantErrsLen: 7,
+		},
+		{
+			name: "test submit jobs",
+			args: args{
+				concurrency: 2,
+				opts:        []OptionPool{WithError(nil), WithErrors(nil)},
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				job := func() {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+				}
+				assert.ErrorIs(t, bwp.Submit(nil), ErrNilJob)
+				assert.ErrorIs(t, bwp.SubmitSimple(nil), ErrNilJob)
+				assert.NoError(t, bwp.SubmitSimple(job))
+				assert.NoError(t, bwp.Submit(func() error {
+					job()
+					return errors.New("an error")
+				}))
+				bwp.Shutdown()
+				// The errors returned by Submit are not reported to the pool
+				assert.ErrorIs(t, bwp.SubmitSimple(job), ErrPoolClosed)
+				assert.ErrorIs(t, bwp.Submit(func() error { return nil }), ErrPoolClosed)
+				return &ret
+			},
+			wantRet:     2,
+			wantErr:     true,
+			wantErrsLen: 1,
+		},
+		{
+			name: "test submit jobs with concurrent shutdown",
+			args: args{
+				concurrency: 4,
+				opts:        []OptionPool{WithError(nil), WithErrors(nil)},
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret, submitted int64
+				var mu = &sync.Mutex{}
+				var wg = &sync.WaitGroup{}
+
+				for i := 0; i < 8; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for {
+							err := bwp.SubmitSimple(func() {
+								mu.Lock()
+								defer mu.Unlock()
+								ret++
+							})
+							if err != nil {
+								assert.ErrorIs(t, err, ErrPoolClosed)
+								return
+							}
+							mu.Lock()
+							submitted++
+							mu.Unlock()
+						}
+					}()
+				}
+				time.Sleep(time.Millisecond * 50)
+				bwp.Shutdown()
+				wg.Wait()
+				// Every accepted job is executed before Shutdown returns
+				mu.Lock()
+				defer mu.Unlock()
+				assert.Equal(t, submitted, ret)
+				ret = 0
+				return &ret
+			},
+			wantRet:     0,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test restart",
+			args: args{
+				concurrency: 2,
+				opts:        []OptionPool{WithError(nil), WithErrors(nil)},
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				job := func() {
+					time.Sleep(time.Millisecond * 100)
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+				}
+				assert.Equal(t, StateRunning, bwp.State())
+				bwp.Pause()
+				assert.Equal(t, StatePaused, bwp.State())
+				bwp.DoSimple(job)
+				go func() {
+					time.Sleep(time.Millisecond * 50)
+					assert.Equal(t, StateDraining, bwp.State())
+				}()
+				// The queued job is completed before the pool is stopped
+				bwp.Shutdown()
+				assert.Equal(t, StateStopped, bwp.State())
+				bwp.DoSimple(job)
+				// The pool is restarted with the same options
+				bwp.Restart()
+				assert.Equal(t, StateRunning, bwp.State())
+				assert.False(t, bwp.IsDead())
+				bwp.DoSimple(job)
+				bwp.Do(func() error {
+					return errors.New("an error")
+				})
+				bwp.Wait()
+				// Restart a running pool shuts it down first
+				bwp.DoSimple(job)
+				bwp.Restart()
+				assert.Equal(t, StateRunning, bwp.State())
+				mu.Lock()
+				defer mu.Unlock()
+				assert.Equal(t, int64(3), ret)
+				return &ret
+			},
+			wantRet:     3,
+			wantErr:     true,
+			wantErrsLen: 2, // ErrPoolClosed + an error
+		},
 		{
 			name: "test execute jobs with retry",
 			args: args{
@@ -111,6 +310,461 @@ func TestWorkerPool(t *testing.T) {
 			wantErr:     true,
 			wantErrsLen: 500,
 		},
+		{
+			name: "test execute jobs with priority",
+			args: args{
+				concurrency: 1,
+				opts:        []OptionPool{WithPriorityAging(time.Hour)},
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+				var got []string
+
+				record := func(name string) func() error {
+					return func() error {
+						mu.Lock()
+						defer mu.Unlock()
+						got = append(got, name)
+						ret++
+						return nil
+					}
+				}
+				bwp.DoSimple(func() { // Consumed by the worker
+					time.Sleep(time.Millisecond * 300)
+				})
+				go bwp.DoPriority(0, record("low1")) // Queued at pool (not blocking)
+				time.Sleep(time.Millisecond * 50)
+				go bwp.DoPriority(0, record("low2")) // Blocked (blocking)
+				time.Sleep(time.Millisecond * 50)
+				bwp.DoPriority(10, record("high")) // Blocked, but jump the queue
+				bwp.Wait()
+				assert.Equal(t, []string{"high", "low1", "low2"}, got)
+				return &ret
+			},
+			wantRet:     3,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test execute jobs with classes",
+			args: args{
+				concurrency: 1,
+				opts:        []OptionPool{WithClasses(map[string]int{"interactive": 3, "background": 1})},
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+				var got []string
+
+				bwp.DoSimple(func() { // Consumed by the worker
+					time.Sleep(time.Millisecond * 300)
+				})
+				for i := 0; i < 4; i++ {
+					for _, class := range []string{"background", "interactive"} {
+						ccp := class
+						go bwp.DoClass(ccp, func() error { // Queued at pool
+							mu.Lock()
+							defer mu.Unlock()
+							got = append(got, ccp)
+							ret++
+							return nil
+						})
+					}
+				}
+				time.Sleep(time.Millisecond * 100)
+				bwp.Wait()
+				// Under contention, 3 interactive jobs are started for every background job
+				assert.ElementsMatch(t, []string{"interactive", "interactive", "interactive", "background"}, got[:4])
+				// When interactive jobs are drained, background jobs use the worker
+				assert.Equal(t, []string{"background", "background"}, got[6:])
+				return &ret
+			},
+			wantRet:     8,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test execute jobs with weight",
+			args: args{
+				concurrency: 4,
+				opts:        []OptionPool{WithWeightCapacity(4)},
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				start := time.Now()
+				for _, weight := range []int64{4, 1, 1} {
+					bwp.DoWeighted(weight, func() error {
+						time.Sleep(time.Millisecond * 500)
+						mu.Lock()
+						defer mu.Unlock()
+						ret++
+						return nil
+					})
+				}
+				bwp.Wait()
+				// The light jobs are started after the heavy job completed. The total executed time should be around ~1 sec
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Second, ts)
+				assert.LessOrEqual(t, ts, time.Second+(time.Millisecond*100)) // Add 0.1s as a threshold
+				return &ret
+			},
+			wantRet:     3,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test execute delayed jobs",
+			args: args{
+				concurrency: 2,
+				opts:        []OptionPool{WithError(nil)},
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+				var got []string
+
+				record := func(name string) func() error {
+					return func() error {
+						mu.Lock()
+						defer mu.Unlock()
+						got = append(got, name)
+						ret++
+						return nil
+					}
+				}
+				start := time.Now()
+				bwp.DoAt(start.Add(time.Millisecond*600), record("at"))
+				bwp.DoAfter(time.Millisecond*300, record("after"))
+				bwp.Do(record("now"))
+				// Delayed jobs are counted by Wait. The total executed time should be around ~600 ms
+				bwp.Wait()
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Millisecond*600, ts)
+				assert.LessOrEqual(t, ts, (time.Millisecond*600)+(time.Millisecond*100)) // Add 0.1s as a threshold
+				assert.Equal(t, []string{"now", "after", "at"}, got)
+				// The queue wait of a delayed job starts at its due time
+				queueWait := bwp.Stats().QueueWait
+				assert.Equal(t, int64(3), queueWait.Count)
+				assert.Less(t, queueWait.Max, time.Millisecond*100)
+				return &ret
+			},
+			wantRet:     3,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test shutdown drops delayed jobs",
+			args: args{
+				concurrency: 2,
+				opts:        nil,
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				bwp.DoAfter(time.Hour, func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return nil
+				})
+				start := time.Now()
+				bwp.Shutdown()
+				// The total block time should 0
+				ts := time.Since(start)
+				assert.LessOrEqual(t, ts, time.Millisecond*100) // Add 0.1s as a threshold
+				bwp.DoAfter(0, func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return nil
+				})
+				return &ret
+			},
+			wantRet:     0,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test execute jobs with handle",
+			args: args{
+				concurrency: 1,
+				opts:        []OptionPool{WithRetry(1), WithError(nil), WithErrors(nil)},
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				started := make(chan struct{})
+				running := bwp.DoHandle(func(ctx context.Context) error { // Consumed by the worker
+					mu.Lock()
+					ret++
+					mu.Unlock()
+					close(started)
+					<-ctx.Done()
+					return ctx.Err()
+				})
+				queued := bwp.DoHandle(func(ctx context.Context) error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return nil
+				})
+				<-started
+				assert.Equal(t, JobRunning, running.Status())
+				assert.Equal(t, JobQueued, queued.Status())
+				// The queued job is skipped by the worker
+				queued.Cancel()
+				<-queued.Done()
+				assert.Equal(t, JobCancelled, queued.Status())
+				assert.NoError(t, queued.Err())
+				// The running job is not retried once cancelled, and its error is not reported
+				running.Cancel()
+				<-running.Done()
+				assert.Equal(t, JobCancelled, running.Status())
+				assert.ErrorIs(t, running.Err(), context.Canceled)
+
+				failed := bwp.DoHandle(func(ctx context.Context) error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return errors.New("an error")
+				})
+				succeeded := bwp.DoHandle(func(ctx context.Context) error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return nil
+				})
+				<-failed.Done()
+				<-succeeded.Done()
+				assert.Equal(t, JobFailed, failed.Status())
+				assert.EqualError(t, failed.Err(), "an error")
+				assert.Equal(t, JobSucceeded, succeeded.Status())
+				// Cancel after the job is completed will perform no-op
+				succeeded.Cancel()
+				assert.Equal(t, JobSucceeded, succeeded.Status())
+				return &ret
+			},
+			wantRet:     1 + (1 + 1) + 1, // running + (base attempt + num retry) + succeeded
+			wantErr:     true,
+			wantErrsLen: 1,
+		},
+		{
+			name: "test execute jobs with timeout",
+			args: args{
+				concurrency: 1,
+				opts: []OptionPool{WithJobTimeout(time.Millisecond * 200), WithJobTimeout(-1), WithRetry(2), WithError(nil), WithErrors(nil),
+					WithRetryIf(func(err error) bool {
+						return errors.Is(err, ErrJobTimeout)
+					}),
+				},
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				var active, overlapped int32
+				start := time.Now()
+				// The job does not see the deadline, the worker waits for it and keeps its result
+				bwp.DoSimple(func() {
+					if atomic.AddInt32(&active, 1) > 1 {
+						atomic.StoreInt32(&overlapped, 1)
+					}
+					defer atomic.AddInt32(&active, -1)
+					mu.Lock()
+					ret++
+					mu.Unlock()
+					time.Sleep(time.Millisecond * 300)
+				})
+				// The job is not retried by WithRetryIf
+				bwp.Do(func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return errors.New("an error")
+				})
+				// The job sees the deadline overridden by WithTimeout
+				handle := bwp.DoHandle(func(ctx context.Context) error {
+					if atomic.AddInt32(&active, 1) > 1 {
+						atomic.StoreInt32(&overlapped, 1)
+					}
+					defer atomic.AddInt32(&active, -1)
+					mu.Lock()
+					ret++
+					mu.Unlock()
+					<-ctx.Done()
+					return ctx.Err()
+				}, WithTimeout(time.Millisecond*100), WithTimeout(-1), nil)
+				<-handle.Done()
+				assert.Equal(t, JobFailed, handle.Status())
+				assert.ErrorIs(t, handle.Err(), ErrJobTimeout)
+				// The job ignores the deadline and returns nil after it, the worker waits for it and keeps its result
+				handle = bwp.DoHandle(func(ctx context.Context) error {
+					if atomic.AddInt32(&active, 1) > 1 {
+						atomic.StoreInt32(&overlapped, 1)
+					}
+					defer atomic.AddInt32(&active, -1)
+					mu.Lock()
+					ret++
+					mu.Unlock()
+					time.Sleep(time.Millisecond * 150)
+					return nil
+				}, WithTimeout(time.Millisecond*100))
+				<-handle.Done()
+				assert.Equal(t, JobSucceeded, handle.Status())
+				// The total executed time should be around ~750 ms: 300 ms + (100 ms * 3) + 150 ms
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Millisecond*750, ts)
+				assert.LessOrEqual(t, ts, (time.Millisecond*750)+(time.Millisecond*100)) // Add 0.1s as a threshold
+				// The attempts never exceed the concurrency level
+				assert.Equal(t, int32(0), atomic.LoadInt32(&overlapped))
+				return &ret
+			},
+			wantRet:     1 + 1 + (1 + 2) + 1, // not timed out + not retried + (base attempt + num retry) + not timed out
+			wantErr:     true,
+			wantErrsLen: 2,
+		},
+		{
+			name: "test execute jobs with watchdog",
+			args: args{
+				concurrency: 2,
+				opts: []OptionPool{WithWatchdog(time.Millisecond*200, func(job StuckJob) {
+					stuckMu.Lock()
+					defer stuckMu.Unlock()
+					stuck = append(stuck, job)
+				})},
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				bwp.DoHandle(func(ctx context.Context) error {
+					time.Sleep(time.Millisecond * 400)
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return nil
+				}, WithLabel("stuck"), nil)
+				bwp.DoSimple(func() {
+					time.Sleep(time.Millisecond * 100)
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+				})
+				bwp.Wait()
+				stuckMu.Lock()
+				defer stuckMu.Unlock()
+				// Only the job running longer than the threshold is reported, once
+				if assert.Len(t, stuck, 1) {
+					assert.Equal(t, "stuck", stuck[0].Label)
+					assert.Contains(t, []int{0, 1}, stuck[0].Worker)
+					assert.LessOrEqual(t, time.Millisecond*200, stuck[0].Elapsed)
+					assert.LessOrEqual(t, stuck[0].Elapsed, (time.Millisecond*200)+(time.Millisecond*100)) // Add 0.1s as a threshold
+				}
+				return &ret
+			},
+			wantRet:     2,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test execute sub-pool jobs with watchdog",
+			args: args{
+				concurrency: 1,
+				opts:        nil,
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+				var subStuck []StuckJob
+
+				release := make(chan struct{})
+				bwp.DoSimple(func() {
+					<-release
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+				})
+				sub := bwp.Sub(1, WithWatchdog(time.Millisecond*200, func(job StuckJob) {
+					mu.Lock()
+					defer mu.Unlock()
+					subStuck = append(subStuck, job)
+				}))
+				sub.DoSimple(func() {
+					time.Sleep(time.Millisecond * 100)
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+				})
+				// The job of the sub-pool is not running while it waits for the worker of the parent
+				time.Sleep(time.Millisecond * 300)
+				assert.Empty(t, sub.(*bWorkerPool).Info().Running)
+				close(release)
+				sub.Wait()
+				bwp.Wait()
+				mu.Lock()
+				defer mu.Unlock()
+				// The time the job waits in the queue of the parent is not reported by the watchdog of the sub-pool
+				assert.Empty(t, subStuck)
+				return &ret
+			},
+			wantRet:     2,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test pause and resume",
+			args: args{
+				concurrency: 2,
+				opts:        nil,
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				job := func() {
+					time.Sleep(time.Millisecond * 200)
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+				}
+				bwp.DoSimple(job)
+				time.Sleep(time.Millisecond * 50)
+				start := time.Now()
+				bwp.Pause()
+				assert.True(t, bwp.IsPaused())
+				// Submitted jobs are queued while paused
+				bwp.DoSimple(job)
+				bwp.DoSimple(job)
+				time.Sleep(time.Millisecond * 300)
+				// The in-flight job is completed, the queued jobs are not started
+				mu.Lock()
+				assert.Equal(t, int64(1), ret)
+				mu.Unlock()
+				bwp.Resume()
+				assert.False(t, bwp.IsPaused())
+				bwp.Wait()
+				// The total block time should be around ~500 ms
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Millisecond*500, ts)
+				assert.LessOrEqual(t, ts, (time.Millisecond*500)+(time.Millisecond*100)) // Add 0.1s as a threshold
+				// Shutdown resumes the paused pool to complete the queued jobs
+				bwp.Pause()
+				bwp.DoSimple(job)
+				bwp.Shutdown()
+				assert.False(t, bwp.IsPaused())
+				bwp.Pause()
+				assert.False(t, bwp.IsPaused())
+				return &ret
+			},
+			wantRet:     4,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
 		{
 			name: "test worker startup delay",
 			args: args{
@@ -173,6 +827,317 @@ func TestWorkerPool(t *testing.T) {
 			wantErr:     false,
 			wantErrsLen: 0,
 		},
+		{
+			name: "test stats",
+			args: args{
+				concurrency: 2,
+				opts:        []OptionPool{WithRetry(1), WithError(nil), WithErrors(nil)},
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				assert.Equal(t, Stats{IdleWorkers: 2}, bwp.Stats())
+				release := make(chan struct{})
+				started := make(chan struct{})
+				bwp.DoSimple(func() {
+					close(started)
+					<-release
+				})
+				<-started
+				bwp.DoSimple(func() {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					panic("a panic")
+				})
+				time.Sleep(time.Millisecond * 100)
+				bwp.Pause()
+				bwp.DoSimple(func() {})
+				time.Sleep(time.Millisecond * 100)
+				stats := bwp.Stats()
+				assert.Equal(t, int64(1), stats.Queued)
+				assert.Equal(t, int64(1), stats.Running)
+				assert.Equal(t, int64(1), stats.IdleWorkers)
+				close(release)
+				bwp.Resume()
+				bwp.Wait()
+				stats = bwp.Stats()
+				assert.LessOrEqual(t, time.Millisecond*100, stats.BusyTime)
+				assert.Equal(t, int64(4), stats.AttemptTime.Count)
+				stats.BusyTime = 0
+				stats.QueueWait, stats.AttemptTime, stats.ExecutionTime = Histogram{}, Histogram{}, Histogram{}
+				assert.Equal(t, Stats{IdleWorkers: 2, Submitted: 3, Succeeded: 2, Failed: 1, Retried: 1, Panicked: 2}, stats)
+				bwp.Shutdown()
+				assert.Equal(t, int64(0), bwp.Stats().IdleWorkers)
+				return &ret
+			},
+			wantRet:     1 + 1, // base attempt + num retry
+			wantErr:     true,
+			wantErrsLen: 1,
+		},
+		{
+			name: "test hooks",
+			args: args{
+				concurrency: 1,
+				opts: []OptionPool{WithRetry(1), WithError(nil), WithErrors(nil), WithHooks(Hooks{
+					OnSubmit:  hook("submit"),
+					OnStart:   hook("start"),
+					OnSuccess: hook("success"),
+					OnFailure: hook("failure"),
+					OnRetry:   hook("retry"),
+					OnDrop:    hook("drop"),
+				})},
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				bwp.Do(func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return errors.New("an error")
+				})
+				bwp.DoHandle(func(ctx context.Context) error {
+					time.Sleep(time.Millisecond * 100)
+					return nil
+				}, WithLabel("a"))
+				bwp.Wait()
+				hookMu.Lock()
+				assert.Len(t, hooked["submit"], 2)
+				assert.Len(t, hooked["start"], 2)
+				assert.Len(t, hooked["retry"], 1)
+				assert.Equal(t, 1, hooked["retry"][0].Attempt)
+				assert.EqualError(t, hooked["retry"][0].Err, "an error")
+				assert.Len(t, hooked["failure"], 1)
+				assert.EqualError(t, hooked["failure"][0].Err, "an error")
+				assert.Len(t, hooked["success"], 1)
+				assert.Equal(t, "a", hooked["success"][0].Label)
+				assert.LessOrEqual(t, time.Millisecond*100, hooked["success"][0].Elapsed)
+				hookMu.Unlock()
+				bwp.DoAfter(time.Hour, func() error { return nil })
+				bwp.Shutdown()
+				hookMu.Lock()
+				assert.Len(t, hooked["drop"], 1)
+				assert.ErrorIs(t, hooked["drop"][0].Err, ErrPoolClosed)
+				hookMu.Unlock()
+				return &ret
+			},
+			wantRet:     1 + 1, // base attempt + num retry
+			wantErr:     true,
+			wantErrsLen: 1,
+		},
+		{
+			name: "test logger",
+			args: args{
+				concurrency: 1,
+				opts:        []OptionPool{WithLogger(slog.New(slog.NewTextHandler(logs, nil))), WithError(nil), WithErrors(nil)},
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+
+				bwp.DoHandle(func(ctx context.Context) error {
+					ret++
+					return errors.New("an error")
+				}, WithLabel("a"))
+				bwp.Wait()
+				bwp.DoAfter(time.Hour, func() error { return nil })
+				bwp.Shutdown()
+				records := strings.Split(strings.TrimSpace(logs.String()), "\n")
+				wantRecords := []string{
+					`level=ERROR msg="job failed" label=a attempt=0 error="an error"`,
+					`level=INFO msg="pool draining" queued=1 running=0`,
+					`level=WARN msg="job dropped" label="" attempt=0 error="worker pool is closed"`,
+					`level=INFO msg="pool jobs drained"`,
+					`level=INFO msg="pool workers stopped"`,
+					`level=INFO msg="pool stopped" elapsed=`,
+				}
+				if assert.Len(t, records, len(wantRecords)) {
+					for i, want := range wantRecords {
+						assert.Contains(t, records[i], want)
+					}
+				}
+				return &ret
+			},
+			wantRet:     1,
+			wantErr:     true,
+			wantErrsLen: 1,
+		},
+		{
+			name: "test profiling",
+			args: args{
+				concurrency: 1,
+				opts:        []OptionPool{WithName("emails"), WithProfiling()},
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+
+				bwp.DoHandle(func(ctx context.Context) error {
+					ret++
+					goroutines := &bytes.Buffer{}
+					assert.NoError(t, pprof.Lookup("goroutine").WriteTo(goroutines, 1))
+					assert.Contains(t, goroutines.String(), `# labels: {"bworker.job":"a", "bworker.pool":"emails", "bworker.worker":"0"}`)
+					return nil
+				}, WithLabel("a"))
+				bwp.Wait()
+				return &ret
+			},
+			wantRet:     1,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test profiling sub-pool",
+			args: args{
+				concurrency: 1,
+				opts:        []OptionPool{WithName("emails"), WithProfiling()},
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+
+				sub := bwp.Sub(1, WithName("retries"), WithProfiling())
+				sub.DoHandle(func(ctx context.Context) error {
+					ret++
+					// The job runs on the worker of the parent with the labels of the sub-pool
+					goroutines := &bytes.Buffer{}
+					assert.NoError(t, pprof.Lookup("goroutine").WriteTo(goroutines, 1))
+					assert.Contains(t, goroutines.String(), `# labels: {"bworker.job":"b", "bworker.pool":"retries", "bworker.worker":"0"}`)
+					assert.NotContains(t, goroutines.String(), `# labels: {"bworker.job":"b", "bworker.pool":"emails"`)
+					return nil
+				}, WithLabel("b"))
+				sub.Wait()
+				return &ret
+			},
+			wantRet:     1,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test resize",
+			args: args{
+				concurrency: 1,
+				opts:        nil,
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+				job := func() {
+					mu.Lock()
+					ret++
+					mu.Unlock()
+					time.Sleep(time.Millisecond * 200)
+				}
+
+				bwp.Resize(-1)
+				bwp.Resize(3)
+				assert.Equal(t, int64(3), bwp.Stats().IdleWorkers)
+				start := time.Now()
+				for i := 0; i < 3; i++ {
+					bwp.DoSimple(job)
+				}
+				bwp.Wait()
+				// The total block time should be around ~ 200ms since the jobs are executed concurrently
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Millisecond*200, ts)
+				assert.LessOrEqual(t, ts, (time.Millisecond*200)+(time.Millisecond*100)) // Add 0.1s as a threshold
+
+				// The workers above the new concurrency level stop even if the pool is paused
+				bwp.Pause()
+				bwp.Resize(1)
+				bwp.Resume()
+				time.Sleep(time.Millisecond * 100)
+				assert.Equal(t, int64(1), bwp.Stats().IdleWorkers)
+				start = time.Now()
+				for i := 0; i < 2; i++ {
+					bwp.DoSimple(job)
+				}
+				bwp.Wait()
+				// The total block time should be around ~ 400ms since the jobs are executed one by one
+				ts = time.Since(start)
+				assert.LessOrEqual(t, time.Millisecond*400, ts)
+				assert.LessOrEqual(t, ts, (time.Millisecond*400)+(time.Millisecond*100)) // Add 0.1s as a threshold
+
+				// The new concurrency level is applied on Restart
+				bwp.Shutdown()
+				bwp.Resize(2)
+				bwp.Restart()
+				assert.Equal(t, int64(2), bwp.Stats().IdleWorkers)
+				return &ret
+			},
+			wantRet:     5,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test expvar",
+			args: args{
+				concurrency: 2,
+				opts:        []OptionPool{WithExpvar("test_expvar")},
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+
+				bwp.DoSimple(func() {
+					ret++
+				})
+				bwp.Wait()
+				published := func() Stats {
+					var stats Stats
+					assert.NoError(t, json.Unmarshal([]byte(expvar.Get("test_expvar").String()), &stats))
+					return stats
+				}
+				assert.Equal(t, int64(1), published().Succeeded)
+				assert.Equal(t, int64(2), published().IdleWorkers)
+				// The name is held by the pool until it is shut down
+				other := NewBWorkerPool(1, WithExpvar("test_expvar"))
+				other.Shutdown()
+				assert.Equal(t, int64(2), published().IdleWorkers)
+				// The last Stats are published after Shutdown
+				bwp.Shutdown()
+				assert.Equal(t, int64(1), published().Succeeded)
+				assert.Equal(t, int64(0), published().IdleWorkers)
+				return &ret
+			},
+			wantRet:     1,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test stats with latency histograms",
+			args: args{
+				concurrency: 1,
+				opts:        nil,
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				// Too few workers: the queue wait grows while the execution time stays the same
+				for i := 0; i < 4; i++ {
+					bwp.DoSimple(func() {
+						time.Sleep(time.Millisecond * 100)
+						mu.Lock()
+						defer mu.Unlock()
+						ret++
+					})
+				}
+				bwp.Wait()
+				stats := bwp.Stats()
+				assert.Equal(t, int64(4), stats.QueueWait.Count)
+				// The last job is submitted while the second job is executed, and waits for the third job
+				assert.LessOrEqual(t, time.Millisecond*200, stats.QueueWait.P99)
+				assert.LessOrEqual(t, stats.QueueWait.P99, (time.Millisecond*200)+(time.Millisecond*100)) // Add 0.1s as a threshold
+				assert.Equal(t, int64(4), stats.ExecutionTime.Count)
+				assert.LessOrEqual(t, time.Millisecond*100, stats.ExecutionTime.P50)
+				assert.LessOrEqual(t, stats.ExecutionTime.P99, (time.Millisecond*100)+(time.Millisecond*100)) // Add 0.1s as a threshold
+				assert.Equal(t, stats.ExecutionTime.Count, stats.AttemptTime.Count)
+				return &ret
+			},
+			wantRet:     4,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
 		{
 			name: "test clear error",
 			args: args{
@@ -241,6 +1206,40 @@ func TestWorkerPool(t *testing.T) {
 			wantErr:     false,
 			wantErrsLen: 0,
 		},
+		{
+			name: "test hooks calling the pool during shutdown",
+			args: args{
+				concurrency: 1,
+				opts: []OptionPool{WithHooks(Hooks{OnSubmit: func(info JobInfo) {
+					submitHook(info)
+				}})},
+			},
+			jobs: func(bwp BWorkerPool) *int64 {
+				var ret int64
+
+				shutdown := make(chan struct{})
+				submitHook = func(JobInfo) {
+					go func() {
+						bwp.Shutdown()
+						close(shutdown)
+					}()
+					// The submission does not hold a lock of the pool, so Shutdown is not blocked by the hook
+					for !bwp.IsDead() {
+						time.Sleep(time.Millisecond)
+					}
+					assert.Equal(t, int64(1), bwp.Stats().Queued)
+				}
+				bwp.DoSimple(func() {
+					atomic.AddInt64(&ret, 1)
+				})
+				// Shutdown waits for the submitted job to be executed
+				<-shutdown
+				return &ret
+			},
+			wantRet:     1,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -279,3 +1278,12 @@ func TestWorkerPool(t *testing.T) {
 		})
 	}
 }
+
+func TestState(t *testing.T) {
+	assert.Equal(t, "created", StateCreated.String())
+	assert.Equal(t, "running", StateRunning.String())
+	assert.Equal(t, "paused", StatePaused.String())
+	assert.Equal(t, "draining", StateDraining.String())
+	assert.Equal(t, "stopped", StateStopped.String())
+	assert.Equal(t, "State(10)", State(10).String())
+}
@@ -0,0 +1,270 @@
+package pool
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSub(t *testing.T) {
+	type args struct {
+		concurrency    int
+		maxConcurrency int
+		opts           []OptionPool
+	}
+	tests := []struct {
+		name        string
+		args        args
+		jobs        func(bwp BWorkerPool, sub BWorkerPool) *int64
+		wantRet     int64
+		wantErr     bool
+		wantErrsLen int
+	}{
+		{
+			name: "test use default value",
+			args: args{
+				concurrency:    1,
+				maxConcurrency: -1,
+				opts:           nil,
+			},
+			jobs:        nil,
+			wantRet:     0,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test execute jobs with retry",
+			args: args{
+				concurrency:    10,
+				maxConcurrency: 5,
+				opts:           []OptionPool{WithRetry(3), WithError(nil), WithErrors(nil)}, // Error will be masked at runner
+			},
+			jobs: func(bwp BWorkerPool, sub BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				numJob, wantErrLen := 200, 50
+				for i := 0; i < numJob; i++ {
+					icp := i
+					sub.Do(func() error {
+						mu.Lock()
+						defer mu.Unlock()
+						ret++
+						if icp < wantErrLen {
+							return errors.New("an error")
+						}
+						return nil
+					})
+					sub.DoSimple(func() {
+						mu.Lock()
+						defer mu.Unlock()
+						ret++
+					})
+				}
+				sub.Wait()
+				return &ret
+			},
+			wantRet:     (50 * (1 + 3)) + 150 + 200, // (wantErrLen*(1+numRetry)) + doSuccessLen + doSimple
+			wantErr:     true,
+			wantErrsLen: 50,
+		},
+		{
+			name: "test limit the concurrency of the sub-pool",
+			args: args{
+				concurrency:    4,
+				maxConcurrency: 2,
+				opts:           nil,
+			},
+			jobs: func(bwp BWorkerPool, sub BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				start := time.Now()
+				for i := 0; i < 4; i++ {
+					sub.DoSimple(func() {
+						time.Sleep(time.Millisecond * 300)
+						mu.Lock()
+						defer mu.Unlock()
+						ret++
+					})
+				}
+				sub.Wait()
+				// 4 jobs with 2 concurrency, the total executed time should be around ~600 ms
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Millisecond*600, ts)
+				assert.LessOrEqual(t, ts, (time.Millisecond*600)+(time.Millisecond*100)) // Add 0.1s as a threshold
+				return &ret
+			},
+			wantRet:     4,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test share the concurrency of the parent",
+			args: args{
+				concurrency:    2,
+				maxConcurrency: 2,
+				opts:           nil,
+			},
+			jobs: func(bwp BWorkerPool, sub BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				job := func() {
+					time.Sleep(time.Millisecond * 300)
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+				}
+				start := time.Now()
+				other := bwp.Sub(2)
+				nested := other.Sub(2)
+				for i := 0; i < 2; i++ {
+					bwp.DoSimple(job)
+					sub.DoSimple(job)
+					nested.DoSimple(job)
+				}
+				bwp.Wait()
+				sub.Wait()
+				nested.Wait()
+				// 6 jobs with 2 workers of the parent, the total executed time should be around ~900 ms
+				ts := time.Since(start)
+				assert.LessOrEqual(t, time.Millisecond*900, ts)
+				assert.LessOrEqual(t, ts, (time.Millisecond*900)+(time.Millisecond*100)) // Add 0.1s as a threshold
+				return &ret
+			},
+			wantRet:     6,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test shut down the sub-pools with the parent",
+			args: args{
+				concurrency:    2,
+				maxConcurrency: 1,
+				opts:           nil,
+			},
+			jobs: func(bwp BWorkerPool, sub BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				nested := sub.Sub(1)
+				for _, p := range []BWorkerPool{sub, nested} {
+					p.DoSimple(func() {
+						time.Sleep(time.Millisecond * 200)
+						mu.Lock()
+						defer mu.Unlock()
+						ret++
+					})
+				}
+				// The jobs of the sub-pools are completed before the parent is shut down
+				bwp.Shutdown()
+				assert.True(t, sub.IsDead())
+				assert.True(t, nested.IsDead())
+				mu.Lock()
+				assert.Equal(t, int64(2), ret)
+				mu.Unlock()
+				assert.True(t, bwp.Sub(1).IsDead())
+				return &ret
+			},
+			wantRet:     2,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test restart the sub-pool",
+			args: args{
+				concurrency:    2,
+				maxConcurrency: 1,
+				opts:           nil,
+			},
+			jobs: func(bwp BWorkerPool, sub BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				bwp.Shutdown()
+				// The parent is shut down, the sub-pool is not restarted
+				sub.Restart()
+				assert.Equal(t, StateStopped, sub.State())
+				bwp.Restart()
+				sub.Restart()
+				assert.Equal(t, StateRunning, sub.State())
+				sub.DoSimple(func() {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+				})
+				sub.Wait()
+				// The restarted sub-pool is shut down with the parent again
+				bwp.Shutdown()
+				assert.True(t, sub.IsDead())
+				bwp.Restart()
+				return &ret
+			},
+			wantRet:     1,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test shut down the sub-pool only",
+			args: args{
+				concurrency:    2,
+				maxConcurrency: 1,
+				opts:           nil,
+			},
+			jobs: func(bwp BWorkerPool, sub BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				sub.Shutdown()
+				sub.DoSimple(func() {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+				})
+				assert.False(t, bwp.IsDead())
+				bwp.DoSimple(func() {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+				})
+				return &ret
+			},
+			wantRet:     1,
+			wantErr:     false,
+			wantErrsLen: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				err  error
+				errs []error
+			)
+			for _, opt := range tt.args.opts {
+				switch o := opt.(type) {
+				case *withError:
+					o.e = &err
+				case *withErrors:
+					o.es = &errs
+				}
+			}
+			bwp := NewBWorkerPool(tt.args.concurrency)
+			defer bwp.Shutdown()
+			sub := bwp.Sub(tt.args.maxConcurrency, tt.args.opts...)
+			if tt.jobs != nil {
+				gotNumExecuted := tt.jobs(bwp, sub)
+				bwp.Wait()
+				assert.Equal(t, tt.wantRet, *gotNumExecuted)
+			}
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantErrsLen, len(errs))
+		})
+	}
+}
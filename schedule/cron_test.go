@@ -0,0 +1,139 @@
+package schedule
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCron(t *testing.T) {
+	// 2024-01-15 is a Monday
+	base := time.Date(2024, time.January, 15, 10, 30, 15, 0, time.UTC)
+	tests := []struct {
+		name     string
+		expr     string
+		from     time.Time
+		wantErr  bool
+		wantNext []time.Time
+	}{
+		{
+			name:    "test invalid number of fields",
+			expr:    "* * * *",
+			wantErr: true,
+		},
+		{
+			name:    "test invalid value",
+			expr:    "60 * * * *",
+			wantErr: true,
+		},
+		{
+			name:    "test invalid range",
+			expr:    "* 10-5 * * *",
+			wantErr: true,
+		},
+		{
+			name:    "test invalid step",
+			expr:    "*/0 * * * *",
+			wantErr: true,
+		},
+		{
+			name:    "test invalid name",
+			expr:    "* * * foo *",
+			wantErr: true,
+		},
+		{
+			name: "test every minute",
+			expr: "* * * * *",
+			from: base,
+			wantNext: []time.Time{
+				time.Date(2024, time.January, 15, 10, 31, 0, 0, time.UTC),
+				time.Date(2024, time.January, 15, 10, 32, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "test every 20 seconds with 6 fields",
+			expr: "*/20 * * * * *",
+			from: base,
+			wantNext: []time.Time{
+				time.Date(2024, time.January, 15, 10, 30, 20, 0, time.UTC),
+				time.Date(2024, time.January, 15, 10, 30, 40, 0, time.UTC),
+				time.Date(2024, time.January, 15, 10, 31, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "test list and range with step",
+			expr: "0,30 9-17/4 * * *",
+			from: base,
+			wantNext: []time.Time{
+				time.Date(2024, time.January, 15, 13, 0, 0, 0, time.UTC),
+				time.Date(2024, time.January, 15, 13, 30, 0, 0, time.UTC),
+				time.Date(2024, time.January, 15, 17, 0, 0, 0, time.UTC),
+				time.Date(2024, time.January, 15, 17, 30, 0, 0, time.UTC),
+				time.Date(2024, time.January, 16, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "test names and sunday as 7",
+			expr: "0 0 * feb-mar sat,7",
+			from: base,
+			wantNext: []time.Time{
+				time.Date(2024, time.February, 3, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.February, 4, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.February, 10, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "test day of month or day of week",
+			expr: "0 0 1 * mon",
+			from: base,
+			wantNext: []time.Time{
+				time.Date(2024, time.January, 22, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.January, 29, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.February, 5, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "test descriptor",
+			expr: "@monthly",
+			from: base,
+			wantNext: []time.Time{
+				time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "test leap day",
+			expr: "0 0 29 2 *",
+			from: base,
+			wantNext: []time.Time{
+				time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+				time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "test never matched",
+			expr: "0 0 30 2 *",
+			from: base,
+			wantNext: []time.Time{
+				{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, err := parseCron(tt.expr)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, cs)
+				return
+			}
+			assert.NoError(t, err)
+			at := tt.from
+			for _, want := range tt.wantNext {
+				at = cs.next(at)
+				assert.Equal(t, want, at)
+			}
+		})
+	}
+}
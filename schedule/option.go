@@ -0,0 +1,55 @@
+package schedule
+
+import (
+	"github.com/bearaujus/bworker/internal"
+	"time"
+)
+
+type OptionEntry interface {
+	Apply(o *internal.OptionEntry)
+}
+
+// Overlap is the policy applied when a job is due while its previous run is still queued or executed in the
+// worker pool. A run is completed once its last retry attempt is finished.
+type Overlap int
+
+const (
+	// OverlapSkip skip the run when the previous run is not completed yet.
+	OverlapSkip Overlap = iota
+	// OverlapQueue defer the run until the previous run is completed. Deferred runs are executed one after another.
+	OverlapQueue
+	// OverlapAllow submit the run regardless of the previous run, so runs of the same job may be executed
+	// concurrently.
+	OverlapAllow
+)
+
+// WithOverlap set the policy applied when a job is due while its previous run is not completed yet.
+// If you're not using this option, the default policy is OverlapSkip.
+func WithOverlap(overlap Overlap) OptionEntry {
+	return &withOverlap{overlap}
+}
+
+type withOverlap struct{ overlap Overlap }
+
+func (w *withOverlap) Apply(o *internal.OptionEntry) {
+	if w.overlap < OverlapSkip || w.overlap > OverlapAllow {
+		return
+	}
+	o.Overlap = int(w.overlap)
+}
+
+// WithJitter set a random delay between 0 and d added to every run of a job.
+//
+// This option is useful to spread jobs that are due at the same time, so they don't hit the same resource at once.
+func WithJitter(d time.Duration) OptionEntry {
+	return &withJitter{d}
+}
+
+type withJitter struct{ d time.Duration }
+
+func (w *withJitter) Apply(o *internal.OptionEntry) {
+	if w.d <= 0 {
+		return
+	}
+	o.Jitter = w.d
+}
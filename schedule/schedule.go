@@ -0,0 +1,199 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bearaujus/bworker/internal"
+	"github.com/bearaujus/bworker/pool"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrScheduleStopped is returned when registering a job after BWorkerSchedule.Stop.
+var ErrScheduleStopped = errors.New("schedule is stopped")
+
+type BWorkerSchedule interface {
+	// Every register a job to be submitted to the worker pool every interval d, starting d after the registration.
+	Every(d time.Duration, job func() error, opts ...OptionEntry) error
+
+	// Cron register a job to be submitted to the worker pool at every time matching the cron expression in the
+	// local time zone. The expression has 5 fields (minute, hour, day of month, month, day of week), 6 fields
+	// (second, minute, hour, day of month, month, day of week), or is one of @yearly, @annually, @monthly,
+	// @weekly, @daily, @midnight and @hourly.
+	//
+	// Each field accepts *, ?, a value, a range (1-5), a step (*/15 or 1-30/5), a list of them (1,15,30), and names
+	// for the month (jan-dec) and day of week (sun-sat) fields.
+	Cron(expr string, job func() error, opts ...OptionEntry) error
+
+	// Stop stop submitting jobs and wait for the registered jobs to stop. Jobs already submitted to the worker pool
+	// are not affected. If IsDead this function will perform no-op.
+	Stop()
+
+	// IsDead indicates the BWorkerSchedule is already stopped or not.
+	IsDead() bool
+}
+
+type bWorkerSchedule struct {
+	bwp        pool.BWorkerPool
+	ctxManager *internal.CtxManager
+	wgEntry    *sync.WaitGroup
+}
+
+type entry struct {
+	next    func(t time.Time) time.Time
+	job     func() error
+	overlap Overlap
+	jitter  time.Duration
+	mu      *sync.Mutex
+	// active indicates a run is submitted and not completed yet, and deferred is the number of runs waiting for
+	// it when using OverlapQueue.
+	active   bool
+	deferred int
+}
+
+// NewBWorkerSchedule create a new BWorkerSchedule that submits the registered jobs to the BWorkerPool. The jobs
+// share the retry and error behaviour of the BWorkerPool. The runs are skipped while the BWorkerPool is shut down and
+// submitted again once it is restarted. A run is queued even if the BWorkerPool is full or paused, so Stop is never
+// blocked by the pool. With another implementation of BWorkerPool, the runs are submitted with Do and allowed to
+// overlap, and Stop waits for a blocked Do.
+//
+// Please use BWorkerSchedule.Stop() to avoid goroutine leak from the registered job(s).
+func NewBWorkerSchedule(bwp pool.BWorkerPool) BWorkerSchedule {
+	return &bWorkerSchedule{
+		bwp:        bwp,
+		ctxManager: internal.NewCtxManager(),
+		wgEntry:    &sync.WaitGroup{},
+	}
+}
+
+func (bws *bWorkerSchedule) Every(d time.Duration, job func() error, opts ...OptionEntry) error {
+	if d <= 0 {
+		return fmt.Errorf("invalid interval %v: must be positive", d)
+	}
+	return bws.register(func(t time.Time) time.Time {
+		return t.Add(d)
+	}, job, opts)
+}
+
+func (bws *bWorkerSchedule) Cron(expr string, job func() error, opts ...OptionEntry) error {
+	cs, err := parseCron(expr)
+	if err != nil {
+		return err
+	}
+	return bws.register(cs.next, job, opts)
+}
+
+func (bws *bWorkerSchedule) register(next func(t time.Time) time.Time, job func() error, opts []OptionEntry) error {
+	if job == nil {
+		return errors.New("job is nil")
+	}
+	o := &internal.OptionEntry{}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt.Apply(o)
+	}
+	e := &entry{
+		next:    next,
+		job:     job,
+		overlap: Overlap(o.Overlap),
+		jitter:  o.Jitter,
+		mu:      &sync.Mutex{},
+	}
+	// The entry is counted while the schedule is alive, so Stop always waits for it
+	if !bws.ctxManager.IfAlive(func() {
+		bws.wgEntry.Add(1)
+		go bws.run(e)
+	}) {
+		return ErrScheduleStopped
+	}
+	return nil
+}
+
+func (bws *bWorkerSchedule) run(e *entry) {
+	defer bws.wgEntry.Done()
+	ctx := bws.ctxManager.Ctx()
+	at := time.Now()
+	for {
+		at = e.next(at)
+		if now := time.Now(); at.Before(now) {
+			// The previous run was submitted late, skip the missed runs instead of catching up
+			at = e.next(now)
+		}
+		if at.IsZero() {
+			return
+		}
+		d := time.Until(at)
+		if e.jitter > 0 {
+			d += time.Duration(rand.Int63n(int64(e.jitter)))
+		}
+		t := time.NewTimer(d)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return
+		}
+		if bws.bwp.IsDead() {
+			// Skip the run while the pool is shut down, the next runs are submitted once it is restarted
+			continue
+		}
+		bws.fire(e)
+	}
+}
+
+func (bws *bWorkerSchedule) fire(e *entry) {
+	cd, ok := bws.bwp.(internal.ChainDoer)
+	if !ok {
+		// The completion of the runs is unknown, so runs are allowed to overlap
+		bws.bwp.Do(e.job)
+		return
+	}
+	if e.overlap == OverlapAllow {
+		cd.DoChain(internal.Chain{Job: e.job, Next: func(error) bool { return false }})
+		return
+	}
+	e.mu.Lock()
+	if e.active {
+		if e.overlap == OverlapQueue {
+			e.deferred++
+		}
+		e.mu.Unlock()
+		return
+	}
+	e.active = true
+	e.mu.Unlock()
+	if !cd.DoChain(internal.Chain{Job: e.job, Next: func(error) bool {
+		return bws.next(e)
+	}}) {
+		e.mu.Lock()
+		e.active = false
+		e.mu.Unlock()
+	}
+}
+
+// next mark a run as completed and report whether a deferred run should be executed right away.
+func (bws *bWorkerSchedule) next(e *entry) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.deferred == 0 || bws.ctxManager.IsDead() {
+		e.active = false
+		e.deferred = 0
+		return false
+	}
+	e.deferred--
+	return true
+}
+
+func (bws *bWorkerSchedule) Stop() {
+	if !bws.ctxManager.Cancel() {
+		return
+	}
+	bws.wgEntry.Wait()
+}
+
+func (bws *bWorkerSchedule) IsDead() bool {
+	return bws.ctxManager.IsDead()
+}
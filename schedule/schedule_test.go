@@ -0,0 +1,256 @@
+package schedule
+
+import (
+	"errors"
+	"github.com/bearaujus/bworker/pool"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	type args struct {
+		concurrency int
+		opts        []pool.OptionPool
+	}
+	tests := []struct {
+		name        string
+		args        args
+		jobs        func(bws BWorkerSchedule, bwp pool.BWorkerPool) *int64
+		wantRet     int64
+		wantErrsLen int
+	}{
+		{
+			name: "test register invalid jobs",
+			args: args{
+				concurrency: 1,
+			},
+			jobs: func(bws BWorkerSchedule, bwp pool.BWorkerPool) *int64 {
+				var ret int64
+
+				assert.Error(t, bws.Every(0, func() error { return nil }))
+				assert.Error(t, bws.Every(time.Second, nil))
+				assert.Error(t, bws.Cron("* * *", func() error { return nil }))
+				assert.Error(t, bws.Cron("* * * * *", nil))
+				bws.Stop()
+				assert.True(t, bws.IsDead())
+				assert.ErrorIs(t, bws.Every(time.Second, func() error { return nil }), ErrScheduleStopped)
+				return &ret
+			},
+			wantRet:     0,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test every interval with retry",
+			args: args{
+				concurrency: 1,
+				opts:        []pool.OptionPool{pool.WithRetry(1)},
+			},
+			jobs: func(bws BWorkerSchedule, bwp pool.BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				assert.NoError(t, bws.Every(time.Millisecond*200, func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return errors.New("an error")
+				}, nil))
+				// Runs at 200ms and 400ms, each with 1 retry
+				time.Sleep(time.Millisecond * 500)
+				bws.Stop()
+				return &ret
+			},
+			wantRet:     2 * (1 + 1),
+			wantErrsLen: 2,
+		},
+		{
+			name: "test cron",
+			args: args{
+				concurrency: 1,
+			},
+			jobs: func(bws BWorkerSchedule, bwp pool.BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				start := time.Now()
+				assert.NoError(t, bws.Cron("* * * * * *", func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					// Executed at the start of a second
+					assert.Less(t, time.Now().Nanosecond(), int(time.Millisecond*100))
+					return nil
+				}))
+				time.Sleep(time.Until(start.Truncate(time.Second).Add(time.Second*2 + time.Millisecond*500)))
+				bws.Stop()
+				return &ret
+			},
+			wantRet:     2,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test overlap skip",
+			args: args{
+				concurrency: 10,
+			},
+			jobs: func(bws BWorkerSchedule, bwp pool.BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				assert.NoError(t, bws.Every(time.Millisecond*200, func() error {
+					mu.Lock()
+					ret++
+					mu.Unlock()
+					time.Sleep(time.Millisecond * 500)
+					return nil
+				}, WithOverlap(OverlapSkip)))
+				// Runs at 200ms and 800ms, while runs at 400ms and 600ms are skipped
+				time.Sleep(time.Millisecond * 900)
+				bws.Stop()
+				return &ret
+			},
+			wantRet:     2,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test overlap queue",
+			args: args{
+				concurrency: 10,
+			},
+			jobs: func(bws BWorkerSchedule, bwp pool.BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				var starts []time.Duration
+				start := time.Now()
+				assert.NoError(t, bws.Every(time.Millisecond*200, func() error {
+					mu.Lock()
+					ret++
+					starts = append(starts, time.Since(start))
+					mu.Unlock()
+					time.Sleep(time.Millisecond * 300)
+					return nil
+				}, WithOverlap(OverlapQueue)))
+				// Runs at 200ms, then the runs due at 400ms and 600ms are executed at 500ms and 800ms
+				time.Sleep(time.Millisecond * 850)
+				// The deferred runs are dropped once stopped
+				bws.Stop()
+				bwp.Wait()
+				mu.Lock()
+				defer mu.Unlock()
+				for i, want := range []time.Duration{time.Millisecond * 200, time.Millisecond * 500, time.Millisecond * 800} {
+					assert.LessOrEqual(t, want, starts[i])
+					assert.LessOrEqual(t, starts[i], want+(time.Millisecond*100)) // Add 0.1s as a threshold
+				}
+				return &ret
+			},
+			wantRet:     3,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test overlap allow with jitter",
+			args: args{
+				concurrency: 10,
+			},
+			jobs: func(bws BWorkerSchedule, bwp pool.BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				assert.NoError(t, bws.Every(time.Millisecond*200, func() error {
+					mu.Lock()
+					ret++
+					mu.Unlock()
+					time.Sleep(time.Millisecond * 500)
+					return nil
+				}, WithOverlap(OverlapAllow), WithJitter(time.Millisecond*50), WithOverlap(-1), WithJitter(-1)))
+				// Runs at 200ms, 400ms and 600ms plus jitter
+				time.Sleep(time.Millisecond * 700)
+				bws.Stop()
+				return &ret
+			},
+			wantRet:     3,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test stop when the pool is shut down",
+			args: args{
+				concurrency: 1,
+			},
+			jobs: func(bws BWorkerSchedule, bwp pool.BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				assert.NoError(t, bws.Every(time.Millisecond*100, func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return nil
+				}))
+				bwp.Shutdown()
+				// The runs are skipped while the pool is shut down
+				time.Sleep(time.Millisecond * 250)
+				mu.Lock()
+				assert.Equal(t, int64(0), ret)
+				mu.Unlock()
+				// The runs are submitted again once the pool is restarted
+				bwp.Restart()
+				time.Sleep(time.Millisecond * 200)
+				start := time.Now()
+				bws.Stop()
+				assert.LessOrEqual(t, time.Since(start), time.Millisecond*100)
+				return &ret
+			},
+			wantRet:     2,
+			wantErrsLen: 0,
+		},
+		{
+			name: "test stop when the pool is paused and full",
+			args: args{
+				concurrency: 1,
+				opts:        []pool.OptionPool{pool.WithJobPoolSize(1)},
+			},
+			jobs: func(bws BWorkerSchedule, bwp pool.BWorkerPool) *int64 {
+				var ret int64
+				var mu = &sync.Mutex{}
+
+				bwp.Pause()
+				assert.NoError(t, bws.Every(time.Millisecond*50, func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					ret++
+					return nil
+				}, WithOverlap(OverlapAllow)))
+				time.Sleep(time.Millisecond * 275)
+				// The runs are queued without waiting for room in the pool
+				start := time.Now()
+				bws.Stop()
+				assert.LessOrEqual(t, time.Since(start), time.Millisecond*100)
+				bwp.Resume()
+				return &ret
+			},
+			wantRet:     5,
+			wantErrsLen: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errs []error
+			bwp := pool.NewBWorkerPool(tt.args.concurrency, append(tt.args.opts, pool.WithErrors(&errs))...)
+			defer bwp.Shutdown()
+			bws := NewBWorkerSchedule(bwp)
+			assert.False(t, bws.IsDead())
+			defer func() {
+				bws.Stop()
+				assert.True(t, bws.IsDead())
+			}()
+			if tt.jobs != nil {
+				gotNumExecuted := tt.jobs(bws, bwp)
+				bwp.Wait()
+				assert.Equal(t, tt.wantRet, *gotNumExecuted)
+			}
+			assert.Equal(t, tt.wantErrsLen, len(errs))
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"context"
+	"github.com/bearaujus/bworker/pool"
+	"time"
+)
+
+const (
+	// JobSpanName is the name of the span created for every job.
+	JobSpanName = "bworker.job"
+	// AttemptSpanName is the name of the span created for every attempt of a job, as a child of the job span.
+	AttemptSpanName = "bworker.attempt"
+)
+
+// Provider start a span as a child of the span carried by ctx, and return a context carrying the new span. It can be
+// implemented by a small wrapper of an OpenTelemetry trace.Tracer, so this package does not depend on OpenTelemetry:
+//
+//	func (p otelProvider) Start(ctx context.Context, name string) (context.Context, tracing.Span) {
+//		ctx, span := p.tracer.Start(ctx, name)
+//		return ctx, otelSpan{span}
+//	}
+type Provider interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Provider.
+type Span interface {
+	// SetAttribute set an attribute of the span. The value is a string, an int or a time.Duration.
+	SetAttribute(key string, value interface{})
+
+	// RecordError record the error of the span.
+	RecordError(err error)
+
+	// End complete the span.
+	End()
+}
+
+type (
+	jobKey     struct{}
+	spanKey    struct{}
+	attemptKey struct{}
+)
+
+// job is carried by the context of a job from its submission to its start.
+type job struct {
+	label     string
+	submitted time.Time
+}
+
+type tracer struct {
+	provider Provider
+}
+
+// NewTracer create a pool.Tracer that creates a span named JobSpanName for every job, from the start of the job to
+// its completion, and a child span named AttemptSpanName for every attempt of the job. The job span is a child of
+// the span carried by the context of a job submitted with WithContext, and the job submitted with DoHandle sees the
+// attempt span in its context.
+//
+// The job span has the bworker.label, bworker.queue_wait and bworker.retries attributes, and the attempt span has
+// the bworker.attempt attribute. A job dropped before it is started has no span.
+func NewTracer(provider Provider) pool.Tracer {
+	return &tracer{provider: provider}
+}
+
+func (t *tracer) Submit(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, jobKey{}, &job{label: label, submitted: time.Now()})
+}
+
+func (t *tracer) Start(ctx context.Context) context.Context {
+	ctx, span := t.provider.Start(ctx, JobSpanName)
+	if j, ok := ctx.Value(jobKey{}).(*job); ok {
+		if j.label != "" {
+			span.SetAttribute("bworker.label", j.label)
+		}
+		span.SetAttribute("bworker.queue_wait", time.Since(j.submitted))
+	}
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+func (t *tracer) AttemptStart(ctx context.Context, attempt int) context.Context {
+	ctx, span := t.provider.Start(ctx, AttemptSpanName)
+	span.SetAttribute("bworker.attempt", attempt)
+	return context.WithValue(ctx, attemptKey{}, span)
+}
+
+func (t *tracer) AttemptEnd(ctx context.Context, attempt int, err error) {
+	if span, ok := ctx.Value(attemptKey{}).(Span); ok {
+		end(span, err)
+	}
+}
+
+func (t *tracer) Retry(ctx context.Context, attempt int, err error) {
+	if span, ok := ctx.Value(spanKey{}).(Span); ok {
+		span.SetAttribute("bworker.retries", attempt)
+	}
+}
+
+func (t *tracer) Finish(ctx context.Context, err error) {
+	if span, ok := ctx.Value(spanKey{}).(Span); ok {
+		end(span, err)
+	}
+}
+
+func end(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
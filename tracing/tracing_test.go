@@ -0,0 +1,144 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/bearaujus/bworker/flex"
+	"github.com/bearaujus/bworker/pool"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+type fakeSpanKey struct{}
+
+type fakeSpan struct {
+	name   string
+	parent *fakeSpan
+	mu     *sync.Mutex
+	attrs  map[string]interface{}
+	errs   []error
+	ended  bool
+}
+
+func (s *fakeSpan) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs[key] = value
+}
+
+func (s *fakeSpan) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errs = append(s.errs, err)
+}
+
+func (s *fakeSpan) End() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ended = true
+}
+
+type fakeProvider struct {
+	mu    *sync.Mutex
+	spans []*fakeSpan
+}
+
+func (p *fakeProvider) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := ctx.Value(fakeSpanKey{}).(*fakeSpan)
+	span := &fakeSpan{name: name, parent: parent, mu: p.mu, attrs: make(map[string]interface{})}
+	p.mu.Lock()
+	p.spans = append(p.spans, span)
+	p.mu.Unlock()
+	return context.WithValue(ctx, fakeSpanKey{}, span), span
+}
+
+func TestTracer(t *testing.T) {
+	tests := []struct {
+		name   string
+		jobs   func(tracer pool.Tracer, root context.Context)
+		verify func(root *fakeSpan, spans []*fakeSpan)
+	}{
+		{
+			name: "test job and attempt spans with retry",
+			jobs: func(tracer pool.Tracer, root context.Context) {
+				bwp := pool.NewBWorkerPool(1, pool.WithTracer(tracer), pool.WithRetry(1))
+				defer bwp.Shutdown()
+				var attempts int
+				handle := bwp.DoHandle(func(ctx context.Context) error {
+					attempts++
+					// The job sees the span of its attempt
+					span, _ := ctx.Value(fakeSpanKey{}).(*fakeSpan)
+					assert.Equal(t, AttemptSpanName, span.name)
+					if attempts == 1 {
+						return errors.New("an error")
+					}
+					return nil
+				}, pool.WithContext(root), pool.WithLabel("a"))
+				<-handle.Done()
+				bwp.Wait()
+			},
+			verify: func(root *fakeSpan, spans []*fakeSpan) {
+				assert.Len(t, spans, 3)
+				job := spans[0]
+				assert.Equal(t, JobSpanName, job.name)
+				assert.Equal(t, root, job.parent)
+				assert.Equal(t, "a", job.attrs["bworker.label"])
+				assert.Contains(t, job.attrs, "bworker.queue_wait")
+				assert.Equal(t, 1, job.attrs["bworker.retries"])
+				assert.Empty(t, job.errs)
+				assert.True(t, job.ended)
+				for i, attempt := range spans[1:] {
+					assert.Equal(t, AttemptSpanName, attempt.name)
+					assert.Equal(t, job, attempt.parent)
+					assert.Equal(t, i, attempt.attrs["bworker.attempt"])
+					assert.True(t, attempt.ended)
+				}
+				assert.Len(t, spans[1].errs, 1)
+				assert.Empty(t, spans[2].errs)
+			},
+		},
+		{
+			name: "test failed job without context",
+			jobs: func(tracer pool.Tracer, root context.Context) {
+				bwf := flex.NewBWorkerFlex(flex.WithTracer(tracer))
+				bwf.Do(func() error { return errors.New("an error") })
+				bwf.Wait()
+			},
+			verify: func(root *fakeSpan, spans []*fakeSpan) {
+				assert.Len(t, spans, 2)
+				assert.Nil(t, spans[0].parent)
+				assert.NotContains(t, spans[0].attrs, "bworker.label")
+				assert.Len(t, spans[0].errs, 1)
+				assert.Len(t, spans[1].errs, 1)
+				assert.Equal(t, spans[0], spans[1].parent)
+			},
+		},
+		{
+			name: "test cancelled job has no span",
+			jobs: func(tracer pool.Tracer, root context.Context) {
+				bwp := pool.NewBWorkerPool(1, pool.WithTracer(tracer))
+				defer bwp.Shutdown()
+				bwp.Pause()
+				handle := bwp.DoHandle(func(ctx context.Context) error { return nil }, pool.WithContext(root))
+				handle.Cancel()
+				bwp.Resume()
+				bwp.Wait()
+			},
+			verify: func(root *fakeSpan, spans []*fakeSpan) {
+				assert.Empty(t, spans)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu := &sync.Mutex{}
+			root := &fakeSpan{name: "root", mu: mu, attrs: make(map[string]interface{})}
+			provider := &fakeProvider{mu: mu}
+			tt.jobs(NewTracer(provider), context.WithValue(context.Background(), fakeSpanKey{}, root))
+			mu.Lock()
+			defer mu.Unlock()
+			tt.verify(root, provider.spans)
+		})
+	}
+}